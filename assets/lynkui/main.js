@@ -34,6 +34,7 @@
     tasklet: {},
     pagelet: {
       set: {},
+      applied: {},
     },
     datalet_data_set: {},
     cookie: {},
@@ -180,14 +181,115 @@
       $(document).on("click", ".lynkui-data-row-insert", function () {
         lynkui.pagelet.dataRowInsert($(this));
       });
+      //
+      $(document).on("click", ".lynkui-data-row-delete", function () {
+        lynkui.pagelet.dataRowDelete($(this));
+      });
+      //
+      $(document).on("click", ".lynkui-data-import", function () {
+        lynkui.pagelet.dataImport($(this));
+      });
+      $(document).on("change", "#data-import-file", function () {
+        lynkui.pagelet.dataImportFile(this);
+      });
+      //
+      $(document).on("click", ".lynkui-datalet-page-prev", function () {
+        lynkui.pagelet.dataletPage($(this), -1);
+      });
+      $(document).on("click", ".lynkui-datalet-page-next", function () {
+        lynkui.pagelet.dataletPage($(this), 1);
+      });
+      //
+      $(document).on("click", ".lynkui-datalet-sort", function () {
+        lynkui.pagelet.dataletSort($(this));
+      });
+      //
+      $(document).on("click", ".lynkui-datalet-filter-apply", function () {
+        lynkui.pagelet.dataletFilter($(this), false);
+      });
+      $(document).on("click", ".lynkui-datalet-filter-reset", function () {
+        lynkui.pagelet.dataletFilter($(this), true);
+      });
 
       //
+      if (lynkui.session.enable && !lynkui.session.user) {
+        return lynkui.loginOpen();
+      }
+      if (lynkui.session.user) {
+        lynkui._sessionBar();
+      }
       lynkui.pagelet.run({
         name: "index",
       });
     });
   };
 
+  // loginOpen replaces the console with the login form, the page is
+  // reloaded after a successful login.
+  lynkui.loginOpen = function (msg) {
+    var body = $("#lynkui-body-content");
+    if (body.find(".lynkui-login").length) {
+      return;
+    }
+    lynkui.modal.close();
+    $(".lynkui-session-bar").remove();
+
+    var form = $('<form class="lynkui-login"></form>');
+    form.append($('<h5 class="mb-3"></h5>').text("Sign in"));
+    form.append(
+      '<input type="text" class="form-control mb-2" name="name" placeholder="Name" autocomplete="username" />'
+    );
+    form.append(
+      '<input type="password" class="form-control mb-3" name="password" placeholder="Password" autocomplete="current-password" />'
+    );
+    form.append('<button type="submit" class="btn btn-primary w-100">Sign in</button>');
+    form.append($('<div class="lynkui-login-msg text-danger mt-2"></div>').text(msg || ""));
+
+    form.on("submit", function (e) {
+      e.preventDefault();
+      var req = {
+        name: form.find("input[name=name]").val(),
+        password: form.find("input[name=password]").val(),
+      };
+      lynkui.utilx.ajax(lynkui.basepath + "/api/v1/auth/login", {
+        data: lynkui.utilx.jsonEncode(req),
+        callback: function (err, data) {
+          if (!err && data && data.status && data.status.code == "2000") {
+            return window.location.reload();
+          }
+          var msg = "login failed";
+          if (data && data.status && data.status.message) {
+            msg = data.status.message;
+          }
+          form.find(".lynkui-login-msg").text(msg);
+        },
+      });
+    });
+
+    body.empty().append(form);
+  };
+
+  lynkui.logout = function () {
+    lynkui.utilx.ajax(lynkui.basepath + "/api/v1/auth/logout", {
+      method: "POST",
+      callback: function () {
+        window.location.reload();
+      },
+    });
+  };
+
+  lynkui._sessionBar = function () {
+    var bar = $('<div class="lynkui-session-bar"></div>');
+    bar.append($("<span></span>").text(lynkui.session.user.name));
+    bar.append(
+      $('<a href="#" class="ms-2">Sign out</a>').on("click", function (e) {
+        e.preventDefault();
+        lynkui.logout();
+      })
+    );
+    $("body").append(bar);
+  };
+
   lynkui.newEventProxy = function () {
     var args = arguments,
       ep = EventProxy.create();
@@ -261,6 +363,9 @@
     var url = lynkui.basepath + "/api/v1/pagelet/fetch?name=" + vl.name;
 
     var ep = lynkui.newEventProxy("data", function (data) {
+      if (data && data.status && data.status.code && data.status.code != "2000") {
+        return _alert.open("error", data.status.message);
+      }
       var msg = lynkui.utilx.kindCheck(data, "Pagelet");
       if (msg) {
         return _alert.open("error", msg);
@@ -306,6 +411,8 @@
         });
       }
 
+      pagelet.applied[vl.name] = vl;
+
       pagelet.datalet(vl, function (err, data) {
         if (err) {
           return;
@@ -315,6 +422,7 @@
           toolbar: {},
           opts: {
             update_enable: false,
+            delete_enable: false,
           },
         };
         if (vl.display_name) {
@@ -323,7 +431,10 @@
         if (vl.exp_data_update_enable === true) {
           box.opts.update_enable = true;
         }
-        if (vl.exp_data_create_enable) {
+        if (vl.exp_data_delete_enable === true) {
+          box.opts.delete_enable = true;
+        }
+        if (vl.exp_data_create_enable || vl.exp_data_update_enable) {
           let x_data = {
             pagelet: vl.name,
           };
@@ -331,9 +442,18 @@
             x_data.query_filter = vl.datalet.query_filter;
           }
           // console.log(x_data);
-          box.toolbar.row_insert_x_data = lynkui.utilx.object64Encode(x_data);
+          if (vl.exp_data_create_enable) {
+            box.toolbar.row_insert_x_data =
+              lynkui.utilx.object64Encode(x_data);
+          }
+          box.toolbar.import_x_data = lynkui.utilx.object64Encode(x_data);
         }
 
+        box.toolbar.export_href =
+          lynkui.basepath +
+          "/api/v1/datalet/export?" +
+          pagelet._dataletQuery(vl);
+
         if (!data.rows) {
           data.rows = [];
         }
@@ -366,6 +486,35 @@
 
         data.box = box;
 
+        if (!vl.datalet._offsets) {
+          vl.datalet._offsets = [];
+        }
+        data.sort = {
+          pagelet: vl.name,
+          field: "",
+          type: "",
+        };
+        if (vl.datalet.query_sort) {
+          data.sort.field = vl.datalet.query_sort.field;
+          data.sort.type = vl.datalet.query_sort.type;
+        } else if (vl.datalet.list && vl.datalet.list.sort) {
+          data.sort.field = vl.datalet.list.sort.field;
+          data.sort.type = vl.datalet.list.sort.type || "asc";
+        }
+
+        data.filter = {
+          pagelet: vl.name,
+          fields: pagelet._dataletFilterFields(vl, data.filter_types),
+        };
+
+        data.pager = {
+          pagelet: vl.name,
+          page: vl.datalet._offsets.length + 1,
+          prev: vl.datalet._offsets.length > 0,
+          next: data.next_offset ? true : false,
+          total: data.total || 0,
+        };
+
         lynkui.template.render({
           tplsrc: vl.template.html.html,
           dstid: "lynkui-" + vl.output,
@@ -381,13 +530,180 @@
   };
 
   pagelet.applyRefresh = function (pagelet_name) {
-    let vl = pagelet.set[pagelet_name];
+    let vl = pagelet.applied[pagelet_name];
+    if (!vl) {
+      vl = pagelet.set[pagelet_name];
+    }
     if (!vl) {
       return;
     }
     pagelet.apply(vl);
   };
 
+  // types are the condition types the table supports, the operators
+  // the table can not evaluate are not offered
+  pagelet._dataletFilterFields = function (vl, types) {
+    var fields = [];
+    types = types || [""];
+    if (!vl.datalet.table_spec || !vl.datalet.table_spec.fields) {
+      return fields;
+    }
+    var display_fields = null;
+    if (
+      vl.datalet.list &&
+      vl.datalet.list.display_fields &&
+      vl.datalet.list.display_fields.length > 0
+    ) {
+      display_fields = vl.datalet.list.display_fields;
+    }
+    var prev = {};
+    for (var i in vl.datalet.query_filters) {
+      var fr = vl.datalet.query_filters[i];
+      prev[fr.field + ":" + fr.type] = fr.value;
+    }
+    for (var i in vl.datalet.table_spec.fields) {
+      var field = vl.datalet.table_spec.fields[i];
+      if (display_fields && !display_fields.includes(field.tag_name)) {
+        continue;
+      }
+      var item = {
+        name: field.name,
+        tag_name: field.tag_name,
+        type: field.type,
+        enums: field.enums,
+        _contains: types.includes("contains"),
+        _range: types.includes("gte") && types.includes("lte"),
+        _op: "contains",
+        _value: "",
+        _min: "",
+        _max: "",
+      };
+      switch (field.type) {
+        case "string":
+          if (!item._contains) {
+            item._op = "";
+          }
+          if (prev[field.tag_name + ":"] !== undefined) {
+            item._op = "";
+            item._value = prev[field.tag_name + ":"];
+          } else if (prev[field.tag_name + ":contains"] !== undefined) {
+            item._value = prev[field.tag_name + ":contains"];
+          }
+          break;
+
+        case "int":
+        case "uint":
+        case "float":
+          if (prev[field.tag_name + ":"] !== undefined) {
+            item._value = prev[field.tag_name + ":"];
+          }
+          if (prev[field.tag_name + ":gte"] !== undefined) {
+            item._min = prev[field.tag_name + ":gte"];
+          }
+          if (prev[field.tag_name + ":lte"] !== undefined) {
+            item._max = prev[field.tag_name + ":lte"];
+          }
+          break;
+
+        default:
+          continue;
+      }
+      fields.push(item);
+    }
+    return fields;
+  };
+
+  pagelet.dataletFilter = function (elem, reset) {
+    if (!elem) {
+      return;
+    }
+    var vl = pagelet.applied[elem.attr("x_pagelet")];
+    if (!vl || !vl.datalet) {
+      return;
+    }
+    var filters = [];
+    if (!reset) {
+      $("#lynkui-datalet-filter-" + vl.name)
+        .find(".lynkui-datalet-filter-input")
+        .each(function () {
+          var input = $(this),
+            value = input.val(),
+            type = input.attr("x_type");
+          if (!value || value == "") {
+            return;
+          }
+          if (type == "text") {
+            type = input
+              .closest(".lynkui-datalet-filter-item")
+              .find(".lynkui-datalet-filter-op")
+              .val();
+          }
+          filters.push({
+            field: input.attr("x_field"),
+            type: type,
+            value: value,
+          });
+        });
+    }
+    vl.datalet.query_filters = filters;
+    vl.datalet.query_offset = "";
+    vl.datalet._offsets = [];
+    pagelet.apply(vl);
+  };
+
+  pagelet.dataletSort = function (elem) {
+    if (!elem) {
+      return;
+    }
+    var vl = pagelet.applied[elem.attr("x_pagelet")];
+    var field = elem.attr("x_field");
+    if (!vl || !vl.datalet || !field) {
+      return;
+    }
+    var prev = vl.datalet.query_sort;
+    if (!prev && vl.datalet.list && vl.datalet.list.sort) {
+      prev = vl.datalet.list.sort;
+    }
+    var type = "asc";
+    if (prev && prev.field == field && prev.type != "desc") {
+      type = "desc";
+    }
+    vl.datalet.query_sort = {
+      field: field,
+      type: type,
+    };
+    vl.datalet.query_offset = "";
+    vl.datalet._offsets = [];
+    pagelet.apply(vl);
+  };
+
+  pagelet.dataletPage = function (elem, step) {
+    if (!elem) {
+      return;
+    }
+    var vl = pagelet.applied[elem.attr("x_pagelet")];
+    if (!vl || !vl.datalet) {
+      return;
+    }
+    if (!vl.datalet._offsets) {
+      vl.datalet._offsets = [];
+    }
+    let ds = lynkui.datalet_data_set[vl.name];
+    if (step > 0) {
+      if (!ds || !ds.next_offset) {
+        return;
+      }
+      vl.datalet._offsets.push(vl.datalet.query_offset || "");
+      vl.datalet.query_offset = ds.next_offset;
+    } else {
+      if (vl.datalet._offsets.length == 0) {
+        return;
+      }
+      vl.datalet.query_offset = vl.datalet._offsets.pop();
+    }
+    pagelet.apply(vl);
+  };
+
   pagelet.next = function (vls) {
     if (!vls || vls.length == 0) {
       return;
@@ -451,6 +767,9 @@
         fields: data.spec.fields,
       },
       rows: [],
+      next_offset: data.next_offset,
+      total: data.total,
+      filter_types: data.filter_types || [""],
     };
 
     for (var i in data.rows) {
@@ -497,6 +816,19 @@
     return sets;
   };
 
+  // the types the rows can be ordered by, as dataletSortable of the server
+  pagelet.fieldSortable = function (field) {
+    return [
+      "bool",
+      "int",
+      "uint",
+      "float",
+      "string",
+      "string_term",
+      "string_text",
+    ].includes(field.type);
+  };
+
   pagelet.rowFieldValue = function (spec, row, name) {
     if (
       !spec ||
@@ -588,7 +920,8 @@
     }
 
     var prev_fields = {},
-      row_id = null;
+      row_id = null,
+      row_version = "";
 
     if (is_update) {
       if (!x_data.id) {
@@ -613,6 +946,9 @@
 
       row_id = row.id;
       prev_fields = row.fields;
+      if (ds.row_versions && ds.row_versions[row.id]) {
+        row_version = ds.row_versions[row.id];
+      }
     }
 
     var query_filter = null;
@@ -653,6 +989,9 @@
         if (field._value != "") {
           ro_fields[field.tag_name] = field._value;
         }
+      } else if (lynkui.utilx.arrayObjectHas(field.attrs, "read_only")) {
+        // not writable by the user, shown but never sent
+        field._read_only = true;
       }
 
       if (!field._read_only || is_update) {
@@ -662,6 +1001,7 @@
 
     lynkui.pagelet.dataRowUpsertCache = {
       id: row_id,
+      version: row_version,
       spec: datalet,
       fields: fields,
       ro_fields: ro_fields,
@@ -686,7 +1026,9 @@
       }
       let url =
         lynkui.basepath +
-        "/api/v1/datalet/dict-query?namespaces=" +
+        "/api/v1/datalet/dict-query?pagelet=" +
+        x_data.pagelet +
+        "&namespaces=" +
         ns.join(",");
 
       lynkui.utilx.ajax(url, {
@@ -780,7 +1122,8 @@
       data_spec = lynkui.pagelet.dataRowUpsertCache.spec.table_spec,
       spec_fields = lynkui.pagelet.dataRowUpsertCache.spec.table_spec.fields,
       ro_fields = lynkui.pagelet.dataRowUpsertCache.ro_fields,
-      row_id = lynkui.pagelet.dataRowUpsertCache.id;
+      row_id = lynkui.pagelet.dataRowUpsertCache.id,
+      cache = lynkui.pagelet.dataRowUpsertCache;
 
     let req = {
       instance_name: spec.instance_name,
@@ -799,25 +1142,13 @@
       if (!elem || elem.length < 1) {
         continue;
       }
-      var value = null;
-      switch (spec_field.type) {
-        case "string":
-          value = elem.val();
-          if (!value || value == "") {
-            value = elem.find("option:selected").val();
-          }
-          break;
-
-        case "int":
-        case "uint":
-          value = parseInt(elem.val());
-          break;
-
-        case "float":
-          value = parseFloat(elem.val());
-          break;
+      // values are sent as entered, the server checks and converts them
+      // by the table spec
+      var value = elem.val();
+      if (spec_field.type == "string" && (!value || value == "")) {
+        value = elem.find("option:selected").val();
       }
-      if (!value) {
+      if (value === undefined || value === null) {
         continue;
       }
       // console.log("set field " + spec_field.tag_name + " : " + value);
@@ -828,10 +1159,18 @@
       req.fields.push(name);
       req.values.push(fields[name]);
     }
+    if (row_id && cache.version) {
+      req.version = cache.version;
+    }
     // console.log(req);
     // return;
 
-    var url = lynkui.basepath + "/api/v1/datalet/upsert";
+    pagelet._upsertFieldErrors(spec_fields, null);
+
+    var url =
+      lynkui.basepath +
+      "/api/v1/datalet/upsert" +
+      pagelet._writeQuery(x_data);
     lynkui.utilx.ajax(url, {
       data: lynkui.utilx.jsonEncode(req),
       callback: function (err, data) {
@@ -841,11 +1180,31 @@
         // console.log(data);
         if (!data.status || !data.status.code) {
           return lynkui.modal.footAlert("warn", "unknown error", 3000);
+        } else if (data.status.code == "4090") {
+          return pagelet._upsertConflict(data_spec, fields, data);
         } else if (data.status.code != "2000") {
+          pagelet._upsertFieldErrors(spec_fields, data.errors);
           return lynkui.modal.footAlert("warn", data.status.message, 3000);
         }
         //
         if (row_id) {
+          let ds = lynkui.datalet_data_set[x_data.pagelet];
+          if (ds && data.version) {
+            for (var i in ds.rows) {
+              if (ds.rows[i].id != row_id) {
+                continue;
+              }
+              for (var name in fields) {
+                ds.rows[i].fields[name] = fields[name];
+              }
+              if (!ds.row_versions) {
+                ds.row_versions = {};
+              }
+              ds.row_versions[row_id] = data.version;
+              break;
+            }
+            cache.version = data.version;
+          }
           for (var name in fields) {
             $("#data-row-" + row_id + "-field-" + name).text(
               lynkui.pagelet.rowFieldValue(
@@ -867,6 +1226,393 @@
     });
   };
 
+  // dataImport opens the import form of a datalet table. A selected CSV or
+  // JSONL file is checked by a dry run first, which shows the column
+  // mapping and the rows that would fail.
+  pagelet.dataImport = function (elem) {
+    if (!elem) {
+      return;
+    }
+
+    var x_data = lynkui.utilx.object64Decode(elem.attr("x_data"));
+    if (!x_data || !x_data.pagelet) {
+      return;
+    }
+
+    let pl = lynkui.pagelet.set[x_data.pagelet];
+    if (!pl || !pl.datalet || !pl.datalet.table_spec) {
+      return;
+    }
+
+    lynkui.pagelet.dataImportCache = {
+      x_data: x_data,
+      fields: pl.datalet.table_spec.fields,
+      format: "",
+      data: "",
+    };
+
+    let tpluri =
+      lynkui.basepath +
+      "/" +
+      lynkui.internal_uipath +
+      "/lynkui/tpl/core/v1/data-import-form.html";
+
+    lynkui.modal.open({
+      title: "Import" + (pl.display_name ? " - " + pl.display_name : ""),
+      width: "max",
+      height: "max",
+      tpluri: tpluri,
+      buttons: [
+        {
+          title: "Cancel",
+          style: "btn btn-dark",
+          onclick: "lynkui.modal.close()",
+        },
+        {
+          title: "Validate",
+          style: "btn btn-outline-primary",
+          onclick: "lynkui.pagelet.dataImportCommit(true)",
+        },
+        {
+          title: "Import",
+          style: "btn btn-primary",
+          onclick: "lynkui.pagelet.dataImportCommit(false)",
+        },
+      ],
+    });
+  };
+
+  pagelet.dataImportFile = function (input) {
+    let cache = lynkui.pagelet.dataImportCache;
+    if (!cache || !input.files || input.files.length < 1) {
+      return;
+    }
+
+    let file = input.files[0],
+      name = file.name.toLowerCase();
+    if (name.endsWith(".csv")) {
+      cache.format = "csv";
+    } else if (name.endsWith(".jsonl") || name.endsWith(".ndjson")) {
+      cache.format = "jsonl";
+    } else {
+      return lynkui.modal.footAlert(
+        "warn",
+        "only .csv or .jsonl files can be imported",
+        3000
+      );
+    }
+
+    let reader = new FileReader();
+    reader.onload = function () {
+      cache.data = reader.result;
+      $("#data-import-result").empty();
+      pagelet.dataImportCommit(true);
+    };
+    reader.onerror = function () {
+      lynkui.modal.footAlert("warn", "failed to read the file", 3000);
+    };
+    reader.readAsText(file);
+  };
+
+  pagelet.dataImportCommit = function (dry_run) {
+    let cache = lynkui.pagelet.dataImportCache;
+    if (!cache) {
+      return;
+    }
+    if (!cache.data) {
+      return lynkui.modal.footAlert("warn", "no file selected", 3000);
+    }
+
+    // the mapping is sent once the dry run has shown the columns
+    let mapping = null;
+    $(".data-import-mapping").each(function () {
+      if (!mapping) {
+        mapping = {};
+      }
+      mapping[$(this).attr("x_column")] = $(this).val();
+    });
+
+    let req = {
+      format: cache.format,
+      data: cache.data,
+      dry_run: dry_run === true,
+    };
+    if (mapping) {
+      req.mapping = mapping;
+    }
+
+    var url =
+      lynkui.basepath +
+      "/api/v1/datalet/import" +
+      pagelet._writeQuery(cache.x_data);
+    lynkui.utilx.ajax(url, {
+      data: lynkui.utilx.jsonEncode(req),
+      callback: function (err, data) {
+        if (err) {
+          return lynkui.modal.footAlert("warn", err, 3000);
+        }
+        if (!data.status || !data.status.code) {
+          return lynkui.modal.footAlert("warn", "unknown error", 3000);
+        } else if (data.status.code != "2000") {
+          return lynkui.modal.footAlert("warn", data.status.message, 3000);
+        }
+
+        lynkui.template.render({
+          dstid: "data-import-result",
+          tplid: "data-import-result-tpl",
+          data: {
+            fields: cache.fields,
+            result: data,
+          },
+        });
+
+        if (dry_run) {
+          return;
+        }
+        if (cache.x_data.pagelet) {
+          pagelet.applyRefresh(cache.x_data.pagelet);
+        }
+        cache.data = "";
+        $("#data-import-file").val("");
+
+        return lynkui.modal.footAlert(
+          data.failed > 0 ? "warn" : "ok",
+          data.created +
+            " created, " +
+            data.updated +
+            " updated, " +
+            data.failed +
+            " failed",
+          5000
+        );
+      },
+    });
+  };
+
+  // the server derives the table and the fixed field values of data changes
+  // from the pagelet and its query_filter
+  pagelet._writeQuery = function (x_data) {
+    var q = "?pagelet=" + encodeURIComponent(x_data.pagelet);
+    if (x_data.query_filter && x_data.query_filter.field) {
+      q +=
+        "&query_filter=" +
+        encodeURIComponent(lynkui.utilx.object64Encode(x_data.query_filter));
+    }
+    return q;
+  };
+
+  // shows the per-field errors of an upsert response next to the inputs,
+  // or clears them if errors is empty
+  // shows the values of a row changed by another user next to the inputs. The
+  // form keeps the edited values, a second commit overwrites the row.
+  pagelet._upsertConflict = function (data_spec, fields, data) {
+    let cache = lynkui.pagelet.dataRowUpsertCache;
+    if (!data.current) {
+      return lynkui.modal.footAlert("warn", data.status.message, 5000);
+    }
+    if (cache) {
+      cache.version = data.version;
+    }
+
+    let errors = [];
+    for (var name in fields) {
+      var value = data.current[name];
+      if (value === undefined || value === null) {
+        value = "";
+      }
+      if (String(value) == String(fields[name])) {
+        continue;
+      }
+      errors.push({
+        field: name,
+        message: "current value: " + String(value),
+      });
+    }
+    pagelet._upsertFieldErrors(data_spec.fields, errors);
+
+    return lynkui.modal.footAlert(
+      "warn",
+      data.status.message + ", commit again to overwrite it",
+      5000
+    );
+  };
+
+  pagelet._upsertFieldErrors = function (spec_fields, errors) {
+    for (var i in spec_fields) {
+      var name = spec_fields[i].tag_name;
+      $("#data-row-upsert-field-" + name).removeClass("is-invalid");
+      $("#data-row-upsert-field-error-" + name).text("");
+    }
+    for (var i in errors) {
+      var e = errors[i];
+      $("#data-row-upsert-field-" + e.field).addClass("is-invalid");
+      $("#data-row-upsert-field-error-" + e.field).text(e.message);
+    }
+  };
+
+  pagelet.dataRowDelete = function (elem) {
+    if (!elem) {
+      return;
+    }
+
+    var x_data = lynkui.utilx.object64Decode(elem.attr("x_data"));
+    if (!x_data || !x_data.pagelet || !x_data.id) {
+      return;
+    }
+
+    let pl = lynkui.pagelet.set[x_data.pagelet];
+    if (!pl || !pl.datalet || !pl.datalet.table_name || !pl.datalet.table_spec) {
+      return;
+    }
+
+    let ds = lynkui.datalet_data_set[x_data.pagelet];
+    if (!ds) {
+      return;
+    }
+    let row = null;
+    for (var i in ds.rows) {
+      if (ds.rows[i].id == x_data.id) {
+        row = ds.rows[i];
+        break;
+      }
+    }
+    if (!row || !row.fields) {
+      return;
+    }
+
+    // the filter matches every primary key of the row
+    let spec = pl.datalet.table_spec,
+      filter = { type: "and", inner: [] },
+      pk_desc = [];
+    for (var i in spec.fields) {
+      var field = spec.fields[i];
+      if (
+        lynkui.utilx.arrayObjectHas(spec.primary_fields, field.tag_name) ||
+        lynkui.utilx.arrayObjectHas(field.attrs, "primary_key")
+      ) {
+        if (row.fields[field.tag_name] === undefined) {
+          return lynkui.alert.open(
+            "error",
+            "primary key (" + field.tag_name + ") not found"
+          );
+        }
+        filter.inner.push({
+          field: field.tag_name,
+          value: row.fields[field.tag_name],
+        });
+        pk_desc.push(field.tag_name + " = " + row.fields[field.tag_name]);
+      }
+    }
+    if (filter.inner.length == 0) {
+      return lynkui.alert.open("error", "primary key not found");
+    }
+
+    lynkui.pagelet.dataRowDeleteCache = {
+      x_data: x_data,
+      req: {
+        table_name: pl.datalet.table_name,
+        filter: filter,
+      },
+    };
+
+    lynkui.alert.open(
+      "warn",
+      lynkui.utilx.sprintf(
+        "Are you sure to delete this row (%s) ?",
+        pk_desc.join(", ")
+      ),
+      {
+        title: "Data Delete",
+        buttons: [
+          {
+            title: "Cancel",
+            style: "btn-dark",
+            onclick: "lynkui.alert.close()",
+          },
+          {
+            title: "Delete",
+            style: "btn-danger",
+            onclick: "lynkui.pagelet.dataRowDeleteCommit()",
+          },
+        ],
+      }
+    );
+  };
+
+  pagelet.dataRowDeleteCommit = function () {
+    if (!lynkui.pagelet.dataRowDeleteCache) {
+      return;
+    }
+
+    let x_data = lynkui.pagelet.dataRowDeleteCache.x_data,
+      req = lynkui.pagelet.dataRowDeleteCache.req;
+
+    lynkui.pagelet.dataRowDeleteCache = null;
+
+    var url =
+      lynkui.basepath +
+      "/api/v1/datalet/delete" +
+      pagelet._writeQuery(x_data);
+    lynkui.utilx.ajax(url, {
+      data: lynkui.utilx.jsonEncode(req),
+      callback: function (err, data) {
+        lynkui.alert.close();
+        if (err) {
+          return lynkui.alert.open("error", err);
+        }
+        if (!data.status || !data.status.code) {
+          return lynkui.alert.open("error", "unknown error");
+        } else if (data.status.code != "2000") {
+          return lynkui.alert.open("error", data.status.message);
+        }
+        pagelet.applyRefresh(x_data.pagelet);
+      },
+    });
+  };
+
+  // _healthBanner replaces the output of the pagelet with a notice that
+  // the data instance of its table is unavailable.
+  pagelet._healthBanner = function (vl, msg) {
+    var elem = $("#lynkui-" + vl.output);
+    if (!elem.length) {
+      return lynkui.alert.open("error", msg);
+    }
+    var banner = $('<div class="lynkui-health-banner"></div>');
+    banner.append($("<strong></strong>").text("Data Service Unavailable"));
+    banner.append($("<div></div>").text(msg || ""));
+    elem.empty().append(banner);
+  };
+
+  // _dataletQuery returns the url params of the datalet query of the
+  // pagelet, with the filters and the sort set in the browser.
+  pagelet._dataletQuery = function (vl) {
+    var q = "pagelet=" + encodeURIComponent(vl.name);
+    if (vl.datalet.query_filter && vl.datalet.query_filter.field) {
+      q += lynkui.utilx.sprintf(
+        "&query_filter=%s",
+        encodeURIComponent(
+          lynkui.utilx.object64Encode(vl.datalet.query_filter)
+        )
+      );
+    }
+    if (vl.datalet.query_filters && vl.datalet.query_filters.length > 0) {
+      q += lynkui.utilx.sprintf(
+        "&query_filters=%s",
+        encodeURIComponent(
+          lynkui.utilx.object64Encode(vl.datalet.query_filters)
+        )
+      );
+    }
+    if (vl.datalet.query_sort && vl.datalet.query_sort.field) {
+      q += lynkui.utilx.sprintf(
+        "&sort_field=%s&sort_type=%s",
+        encodeURIComponent(vl.datalet.query_sort.field),
+        encodeURIComponent(vl.datalet.query_sort.type)
+      );
+    }
+    return q;
+  };
+
   pagelet.datalet = function (vl, cb) {
     if (
       !vl ||
@@ -884,12 +1630,10 @@
       return;
     }
 
-    var url = lynkui.basepath + "/api/v1/datalet/run?pagelet=" + vl.name;
-    if (vl.datalet.query_filter && vl.datalet.query_filter.field) {
-      url += lynkui.utilx.sprintf(
-        "&query_filter=%s",
-        lynkui.utilx.object64Encode(vl.datalet.query_filter)
-      );
+    var url =
+      lynkui.basepath + "/api/v1/datalet/run?" + pagelet._dataletQuery(vl);
+    if (vl.datalet.query_offset) {
+      url += "&offset=" + encodeURIComponent(vl.datalet.query_offset);
     }
 
     lynkui.utilx.ajax(url, {
@@ -902,10 +1646,18 @@
         if (msg) {
           return lynkui.alert.open("error", msg);
         }
+        if (data.status && data.status.code && data.status.code != "2000") {
+          return lynkui.alert.open("error", data.status.message);
+        }
         if (data.results) {
           var _data = {};
           for (var i in data.results) {
             if (data.results[i].name == vl.name) {
+              var rs = data.results[i];
+              if (rs.status && rs.status.code == "5030") {
+                pagelet._healthBanner(vl, rs.status.message);
+                return cb(rs.status.message, null);
+              }
               lynkui.datalet_data_set[vl.name] = data.results[i];
               return cb(null, pagelet._dataResultConvert(vl, data.results[i]));
             }
@@ -1660,11 +2412,18 @@
       options.timeout = 10000;
     }
 
+    //
+    var headers = {};
+    if (options.method != "GET" && lynkui.session.csrf_token) {
+      headers["X-Lynkui-Csrf"] = lynkui.session.csrf_token;
+    }
+
     //
     $.ajax({
       url: url,
       type: options.method,
       data: options.data,
+      headers: headers,
       timeout: options.timeout,
       success: function (rsp) {
         if (typeof options.callback === "function") {
@@ -1672,6 +2431,10 @@
         }
       },
       error: function (xhr, textStatus, error) {
+        if (xhr && xhr.status == 401 && lynkui.session.enable) {
+          // the session has expired or was signed out elsewhere
+          return lynkui.loginOpen("session expired, please sign in again");
+        }
         if (typeof options.callback === "function") {
           if (error) {
             options.callback(error, null);
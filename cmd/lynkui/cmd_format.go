@@ -0,0 +1,34 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/lynkdb/lynkui/go/uiserver"
+)
+
+func cmdFormat(args []string) error {
+
+	if len(args) != 1 {
+		return fmt.Errorf("project path not setup")
+	}
+
+	changes, err := uiserver.FormatProject(args[0])
+	for _, relpath := range changes {
+		fmt.Println(relpath)
+	}
+	return err
+}
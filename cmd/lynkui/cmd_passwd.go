@@ -0,0 +1,49 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lynkdb/lynkui/go/uiserver"
+)
+
+// cmdPasswd reads a password from the first line of stdin and prints its
+// hash for the password field of lynkui_users.json.
+func cmdPasswd(args []string) error {
+
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("password not setup")
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return fmt.Errorf("password not setup")
+	}
+
+	hash, err := uiserver.PasswordHash(password)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
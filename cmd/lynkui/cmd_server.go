@@ -0,0 +1,68 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hooto/hlog4g/hlog"
+	"github.com/hooto/httpsrv"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/go/uiserver"
+)
+
+func cmdServer(args []string) error {
+
+	var (
+		fset = flag.NewFlagSet("server", flag.ExitOnError)
+		port = fset.Int("port", 8002, "http port")
+		dev  = fset.Bool("dev", false, "run in dev mode")
+		ro   = fset.Bool("readonly", false, "never write back to the project files")
+		ssr  = fset.Bool("server-render", false, "render the console pages on the server")
+	)
+	fset.Parse(args)
+
+	if fset.NArg() < 1 {
+		return fmt.Errorf("project path not setup")
+	}
+
+	// a single project is mounted at the default entry path, several
+	// projects at the base name of their path
+	for _, path := range fset.Args() {
+		cfg := &lynkui.ServiceConfig{
+			AppProjectPath:     path,
+			AppProjectReadOnly: *ro,
+			ServerRender:       *ssr,
+		}
+		if fset.NArg() > 1 {
+			cfg.UrlEntryPath = "/" + filepath.Base(filepath.Clean(path))
+		}
+		if *dev {
+			cfg.RunMode = "dev"
+		}
+		if _, err := uiserver.NewService(httpsrv.DefaultService, cfg); err != nil {
+			return err
+		}
+		hlog.Printf("info", "project %s, entry path %s", path, cfg.UrlEntryPath)
+	}
+
+	httpsrv.DefaultService.Config.HttpPort = uint16(*port)
+
+	hlog.Printf("info", "lynkui server running, port %d", *port)
+	return httpsrv.DefaultService.Start()
+}
@@ -7,10 +7,12 @@ go 1.24.0
 require (
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/hooto/hlog4g v0.9.5
+	github.com/hooto/htoml4g v0.9.5
 	github.com/hooto/httpsrv v0.12.5
 	github.com/lynkdb/lynkapi v0.0.9
 	github.com/rakyll/statik v0.1.7
 	google.golang.org/protobuf v1.36.10
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -18,7 +20,6 @@ require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/hooto/hauth v0.1.2 // indirect
 	github.com/hooto/hflag4g v0.10.1 // indirect
-	github.com/hooto/htoml4g v0.9.5 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	golang.org/x/net v0.46.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
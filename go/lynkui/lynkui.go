@@ -15,6 +15,9 @@
 package lynkui
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/lynkdb/lynkapi/go/lynkapi"
 )
 
@@ -23,7 +26,72 @@ type ServiceConfig struct {
 	UrlEntryPath   string `json:"url_entry_path" toml:"url_entry_path" yaml:"url_entry_path"`
 	RunMode        string `json:"run_mode,omitempty" toml:"run_mode,omitempty" yaml:"run_mode,omitempty"`
 
+	// AppProjectReadOnly disables writing back to the project files, data
+	// changes are kept in memory only.
+	AppProjectReadOnly bool `json:"app_project_read_only,omitempty" toml:"app_project_read_only,omitempty" yaml:"app_project_read_only,omitempty"`
+
+	// ServerRender renders the console pages on the server by default, a
+	// request may still ask for the browser rendering with render=client.
+	ServerRender bool `json:"server_render,omitempty" toml:"server_render,omitempty" yaml:"server_render,omitempty"`
+
 	AssetsPath string `json:"-" toml:"-" yaml:"-"`
+
+	// Authenticator verifies the console logins. If not set, the users of
+	// lynkui_users.json in the project are used, and without that file the
+	// console is open to everyone.
+	Authenticator Authenticator `json:"-" toml:"-" yaml:"-"`
+
+	// AuditService stores the audit trail of the data changes in its
+	// lynk_audit table. If not set, the trail is appended to
+	// lynkui_audit.jsonl in the project.
+	AuditService lynkapi.DataService `json:"-" toml:"-" yaml:"-"`
+}
+
+// User is a signed in console user.
+type User struct {
+	Name  string   `json:"name" toml:"name" yaml:"name"`
+	Roles []string `json:"roles,omitempty" toml:"roles,omitempty" yaml:"roles,omitempty"`
+	// attributes referenced by the row filters as ${user.<attr>}, e.g. team
+	Attrs map[string]string `json:"attrs,omitempty" toml:"attrs,omitempty" yaml:"attrs,omitempty"`
+}
+
+// Authenticator verifies the name and password of a console login, host
+// apps may implement it on their own user store.
+type Authenticator interface {
+	Authenticate(name, password string) (*User, error)
+}
+
+// AccessPolicy declares the roles of a project in lynkui_access.json, a
+// user is granted the union of the grants of its roles.
+type AccessPolicy struct {
+	Roles []*AccessRole `json:"roles" toml:"roles" yaml:"roles"`
+}
+
+type AccessRole struct {
+	Name string `json:"name" toml:"name" yaml:"name"`
+	// pagelet names the role may open, "*" for all
+	Pagelets []string       `json:"pagelets,omitempty" toml:"pagelets,omitempty" yaml:"pagelets,omitempty"`
+	Tables   []*AccessTable `json:"tables,omitempty" toml:"tables,omitempty" yaml:"tables,omitempty"`
+	// the role may run the project maintenance actions
+	Admin bool `json:"admin,omitempty" toml:"admin,omitempty" yaml:"admin,omitempty"`
+}
+
+type AccessTable struct {
+	// virtual table name of the data layout, "*" for all
+	Name  string `json:"name" toml:"name" yaml:"name"`
+	Read  bool   `json:"read,omitempty" toml:"read,omitempty" yaml:"read,omitempty"`
+	Write bool   `json:"write,omitempty" toml:"write,omitempty" yaml:"write,omitempty"`
+	// field tag names that are not returned to the role
+	HiddenFields []string `json:"hidden_fields,omitempty" toml:"hidden_fields,omitempty" yaml:"hidden_fields,omitempty"`
+	// field tag names the role may read but not write
+	ReadOnlyFields []string `json:"read_only_fields,omitempty" toml:"read_only_fields,omitempty" yaml:"read_only_fields,omitempty"`
+}
+
+// RequestAuthenticator may be implemented by an Authenticator to sign in the
+// requests carrying the credentials of the host app, e.g. its own session
+// cookie. A nil user without error means the request is not signed in.
+type RequestAuthenticator interface {
+	AuthenticateRequest(r *http.Request) (*User, error)
 }
 
 type MainObjectSet struct {
@@ -31,3 +99,46 @@ type MainObjectSet struct {
 
 	// LynkData []*lynkapi.DataDict `json:"lynk_data,omitempty" toml:"lynk_data,omitempty" yaml:"lynk_data,omitempty"`
 }
+
+// ValidateError reports a problem found in a project file.
+type ValidateError struct {
+	File    string `json:"file" toml:"file" yaml:"file"`
+	Pagelet string `json:"pagelet,omitempty" toml:"pagelet,omitempty" yaml:"pagelet,omitempty"`
+	Field   string `json:"field,omitempty" toml:"field,omitempty" yaml:"field,omitempty"`
+	Message string `json:"message" toml:"message" yaml:"message"`
+}
+
+func (it *ValidateError) Error() string {
+	if it.Field != "" {
+		return fmt.Sprintf("%s: %s: %s", it.File, it.Field, it.Message)
+	}
+	return fmt.Sprintf("%s: %s", it.File, it.Message)
+}
+
+// LayoutChange records what a reload of the data layout file changed.
+type LayoutChange struct {
+	Created          int64    `json:"created" toml:"created" yaml:"created"`
+	TablesAdded      []string `json:"tables_added,omitempty" toml:"tables_added,omitempty" yaml:"tables_added,omitempty"`
+	TablesUpdated    []string `json:"tables_updated,omitempty" toml:"tables_updated,omitempty" yaml:"tables_updated,omitempty"`
+	TablesRemoved    []string `json:"tables_removed,omitempty" toml:"tables_removed,omitempty" yaml:"tables_removed,omitempty"`
+	InstancesAdded   []string `json:"instances_added,omitempty" toml:"instances_added,omitempty" yaml:"instances_added,omitempty"`
+	InstancesUpdated []string `json:"instances_updated,omitempty" toml:"instances_updated,omitempty" yaml:"instances_updated,omitempty"`
+	InstancesRemoved []string `json:"instances_removed,omitempty" toml:"instances_removed,omitempty" yaml:"instances_removed,omitempty"`
+	Errors           []string `json:"errors,omitempty" toml:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+func (it *LayoutChange) Empty() bool {
+	return len(it.TablesAdded) == 0 && len(it.TablesUpdated) == 0 && len(it.TablesRemoved) == 0 &&
+		len(it.InstancesAdded) == 0 && len(it.InstancesUpdated) == 0 && len(it.InstancesRemoved) == 0 &&
+		len(it.Errors) == 0
+}
+
+// InstanceHealth is the connection state of a remote data instance.
+type InstanceHealth struct {
+	Name      string `json:"name" toml:"name" yaml:"name"`
+	Healthy   bool   `json:"healthy" toml:"healthy" yaml:"healthy"`
+	Failures  int    `json:"failures,omitempty" toml:"failures,omitempty" yaml:"failures,omitempty"`
+	LastError string `json:"last_error,omitempty" toml:"last_error,omitempty" yaml:"last_error,omitempty"`
+	Checked   int64  `json:"checked,omitempty" toml:"checked,omitempty" yaml:"checked,omitempty"`
+	NextRetry int64  `json:"next_retry,omitempty" toml:"next_retry,omitempty" yaml:"next_retry,omitempty"`
+}
@@ -108,6 +108,7 @@ type Pagelet struct {
 	PostTasklets        []*Tasklet        `protobuf:"bytes,32,rep,name=post_tasklets,json=postTasklets,proto3" json:"post_tasklets,omitempty" toml:"post_tasklets,omitempty" yaml:"post_tasklets,omitempty"`
 	ExpDataCreateEnable bool              `protobuf:"varint,48,opt,name=exp_data_create_enable,json=expDataCreateEnable,proto3" json:"exp_data_create_enable,omitempty" toml:"exp_data_create_enable,omitempty" yaml:"exp_data_create_enable,omitempty"`
 	ExpDataUpdateEnable bool              `protobuf:"varint,49,opt,name=exp_data_update_enable,json=expDataUpdateEnable,proto3" json:"exp_data_update_enable,omitempty" toml:"exp_data_update_enable,omitempty" yaml:"exp_data_update_enable,omitempty"`
+	ExpDataDeleteEnable bool              `protobuf:"varint,50,opt,name=exp_data_delete_enable,json=expDataDeleteEnable,proto3" json:"exp_data_delete_enable,omitempty" toml:"exp_data_delete_enable,omitempty" yaml:"exp_data_delete_enable,omitempty"`
 }
 
 func (x *Pagelet) Reset() {
@@ -226,6 +227,13 @@ func (x *Pagelet) GetExpDataUpdateEnable() bool {
 	return false
 }
 
+func (x *Pagelet) GetExpDataDeleteEnable() bool {
+	if x != nil {
+		return x.ExpDataDeleteEnable
+	}
+	return false
+}
+
 type Tasklet struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -278,9 +286,12 @@ type DataLayout struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Tables    []*DataLayout_VirtualTable `protobuf:"bytes,9,rep,name=tables,proto3" json:"tables,omitempty" toml:"tables,omitempty" yaml:"tables,omitempty"`
-	Connects  []*lynkapi.DataConnect     `protobuf:"bytes,12,rep,name=connects,proto3" json:"connects,omitempty" toml:"connects,omitempty" yaml:"connects,omitempty"`
-	Instances []*lynkapi.DataInstance    `protobuf:"bytes,13,rep,name=instances,proto3" json:"instances,omitempty" toml:"instances,omitempty" yaml:"instances,omitempty"`
+	Tables          []*DataLayout_VirtualTable   `protobuf:"bytes,9,rep,name=tables,proto3" json:"tables,omitempty" toml:"tables,omitempty" yaml:"tables,omitempty"`
+	Connects        []*lynkapi.DataConnect       `protobuf:"bytes,12,rep,name=connects,proto3" json:"connects,omitempty" toml:"connects,omitempty" yaml:"connects,omitempty"`
+	Instances       []*lynkapi.DataInstance      `protobuf:"bytes,13,rep,name=instances,proto3" json:"instances,omitempty" toml:"instances,omitempty" yaml:"instances,omitempty"`
+	InstanceOptions []*DataLayout_InstanceOption `protobuf:"bytes,14,rep,name=instance_options,json=instanceOptions,proto3" json:"instance_options,omitempty" toml:"instance_options,omitempty" yaml:"instance_options,omitempty"`
+	// default request timeout in milliseconds of the instances
+	TimeoutMs int64 `protobuf:"varint,15,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty" toml:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
 }
 
 func (x *DataLayout) Reset() {
@@ -336,6 +347,20 @@ func (x *DataLayout) GetInstances() []*lynkapi.DataInstance {
 	return nil
 }
 
+func (x *DataLayout) GetInstanceOptions() []*DataLayout_InstanceOption {
+	if x != nil {
+		return x.InstanceOptions
+	}
+	return nil
+}
+
+func (x *DataLayout) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
 type DataletSpec struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -849,6 +874,9 @@ type DataLayout_VirtualTable struct {
 	// string model = 2;
 	RefInstance string `protobuf:"bytes,8,opt,name=ref_instance,json=refInstance,proto3" json:"ref_instance,omitempty" toml:"ref_instance,omitempty" yaml:"ref_instance,omitempty"`
 	RefTable    string `protobuf:"bytes,9,opt,name=ref_table,json=refTable,proto3" json:"ref_table,omitempty" toml:"ref_table,omitempty" yaml:"ref_table,omitempty"`
+	// filter on the rows a signed in user may read and write, values may
+	// reference the user as ${user.name} or ${user.<attr>}
+	RowFilter *lynkapi.DataQuery_Filter `protobuf:"bytes,10,opt,name=row_filter,json=rowFilter,proto3" json:"row_filter,omitempty" toml:"row_filter,omitempty" yaml:"row_filter,omitempty"`
 }
 
 func (x *DataLayout_VirtualTable) Reset() {
@@ -904,6 +932,69 @@ func (x *DataLayout_VirtualTable) GetRefTable() string {
 	return ""
 }
 
+func (x *DataLayout_VirtualTable) GetRowFilter() *lynkapi.DataQuery_Filter {
+	if x != nil {
+		return x.RowFilter
+	}
+	return nil
+}
+
+type DataLayout_InstanceOption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty" toml:"name,omitempty" yaml:"name,omitempty"`
+	// request timeout in milliseconds, 0 to use the layout default
+	TimeoutMs int64 `protobuf:"varint,2,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty" toml:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+}
+
+func (x *DataLayout_InstanceOption) Reset() {
+	*x = DataLayout_InstanceOption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lynkui_lynkui_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DataLayout_InstanceOption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataLayout_InstanceOption) ProtoMessage() {}
+
+func (x *DataLayout_InstanceOption) ProtoReflect() protoreflect.Message {
+	mi := &file_lynkui_lynkui_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataLayout_InstanceOption.ProtoReflect.Descriptor instead.
+func (*DataLayout_InstanceOption) Descriptor() ([]byte, []int) {
+	return file_lynkui_lynkui_proto_rawDescGZIP(), []int{3, 1}
+}
+
+func (x *DataLayout_InstanceOption) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DataLayout_InstanceOption) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
 type DataletSpec_DisplayField struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -915,7 +1006,7 @@ type DataletSpec_DisplayField struct {
 func (x *DataletSpec_DisplayField) Reset() {
 	*x = DataletSpec_DisplayField{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_lynkui_lynkui_proto_msgTypes[14]
+		mi := &file_lynkui_lynkui_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -928,7 +1019,7 @@ func (x *DataletSpec_DisplayField) String() string {
 func (*DataletSpec_DisplayField) ProtoMessage() {}
 
 func (x *DataletSpec_DisplayField) ProtoReflect() protoreflect.Message {
-	mi := &file_lynkui_lynkui_proto_msgTypes[14]
+	mi := &file_lynkui_lynkui_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -959,12 +1050,13 @@ type DataletSpec_ListAction struct {
 	DisplayFields []string                      `protobuf:"bytes,2,rep,name=display_fields,json=displayFields,proto3" json:"display_fields,omitempty" toml:"display_fields,omitempty" yaml:"display_fields,omitempty"`
 	Filter        *lynkapi.DataQuery_Filter     `protobuf:"bytes,11,opt,name=filter,proto3" json:"filter,omitempty" toml:"filter,omitempty" yaml:"filter,omitempty"`
 	Sort          *lynkapi.DataQuery_SortFilter `protobuf:"bytes,12,opt,name=sort,proto3" json:"sort,omitempty" toml:"sort,omitempty" yaml:"sort,omitempty"`
+	PageSize      int64                         `protobuf:"varint,13,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty" toml:"page_size,omitempty" yaml:"page_size,omitempty"`
 }
 
 func (x *DataletSpec_ListAction) Reset() {
 	*x = DataletSpec_ListAction{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_lynkui_lynkui_proto_msgTypes[15]
+		mi := &file_lynkui_lynkui_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -977,7 +1069,7 @@ func (x *DataletSpec_ListAction) String() string {
 func (*DataletSpec_ListAction) ProtoMessage() {}
 
 func (x *DataletSpec_ListAction) ProtoReflect() protoreflect.Message {
-	mi := &file_lynkui_lynkui_proto_msgTypes[15]
+	mi := &file_lynkui_lynkui_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1014,6 +1106,13 @@ func (x *DataletSpec_ListAction) GetSort() *lynkapi.DataQuery_SortFilter {
 	return nil
 }
 
+func (x *DataletSpec_ListAction) GetPageSize() int64 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
 type TemplateNav_Item struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1026,7 +1125,7 @@ type TemplateNav_Item struct {
 func (x *TemplateNav_Item) Reset() {
 	*x = TemplateNav_Item{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_lynkui_lynkui_proto_msgTypes[17]
+		mi := &file_lynkui_lynkui_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1039,7 +1138,7 @@ func (x *TemplateNav_Item) String() string {
 func (*TemplateNav_Item) ProtoMessage() {}
 
 func (x *TemplateNav_Item) ProtoReflect() protoreflect.Message {
-	mi := &file_lynkui_lynkui_proto_msgTypes[17]
+	mi := &file_lynkui_lynkui_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1078,7 +1177,7 @@ var file_lynkui_lynkui_proto_rawDesc = []byte{
 	0x6f, 0x22, 0x31, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x12, 0x0a, 0x04,
 	0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64,
 	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x22, 0x90, 0x05, 0x0a, 0x07, 0x50, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74,
+	0x6e, 0x61, 0x6d, 0x65, 0x22, 0xc5, 0x05, 0x0a, 0x07, 0x50, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74,
 	0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
 	0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
 	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70,
@@ -1110,113 +1209,133 @@ var file_lynkui_lynkui_proto_rawDesc = []byte{
 	0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x33, 0x0a, 0x16, 0x65, 0x78, 0x70, 0x5f, 0x64, 0x61,
 	0x74, 0x61, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65,
 	0x18, 0x31, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x65, 0x78, 0x70, 0x44, 0x61, 0x74, 0x61, 0x55,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x1a, 0x37, 0x0a, 0x09, 0x41,
-	0x72, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x3a, 0x02, 0x38, 0x01, 0x1a, 0x1a, 0x0a, 0x04, 0x4e, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x1a, 0x35, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a,
-	0x07, 0x70, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x70, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74, 0x22, 0x26, 0x0a, 0x07, 0x54, 0x61, 0x73, 0x6b, 0x6c,
-	0x65, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x61, 0x76, 0x5f, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x61, 0x76, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x22,
-	0x90, 0x02, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x37,
-	0x0a, 0x06, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f,
-	0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x4c, 0x61, 0x79, 0x6f,
-	0x75, 0x74, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x52,
-	0x06, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x6e, 0x65,
-	0x63, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x79, 0x6e, 0x6b,
-	0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x52,
-	0x08, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73, 0x12, 0x33, 0x0a, 0x09, 0x69, 0x6e, 0x73,
-	0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6c,
-	0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x49, 0x6e, 0x73, 0x74, 0x61,
-	0x6e, 0x63, 0x65, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x1a, 0x62,
-	0x0a, 0x0c, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x12,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x33, 0x0a, 0x16, 0x65,
+	0x78, 0x70, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x5f, 0x65,
+	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x32, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x65, 0x78, 0x70,
+	0x44, 0x61, 0x74, 0x61, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65,
+	0x1a, 0x37, 0x0a, 0x09, 0x41, 0x72, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x1a, 0x0a, 0x04, 0x4e, 0x65, 0x78,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x1a, 0x35, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12,
 	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x5f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e,
-	0x63, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x66, 0x49, 0x6e, 0x73,
-	0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x5f, 0x74, 0x61, 0x62,
-	0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x66, 0x54, 0x61, 0x62,
-	0x6c, 0x65, 0x22, 0xb0, 0x03, 0x0a, 0x0b, 0x44, 0x61, 0x74, 0x61, 0x6c, 0x65, 0x74, 0x53, 0x70,
-	0x65, 0x63, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x61, 0x6d,
-	0x65, 0x12, 0x31, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x67, 0x65, 0x6c, 0x65, 0x74, 0x22, 0x26, 0x0a, 0x07,
+	0x54, 0x61, 0x73, 0x6b, 0x6c, 0x65, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x61, 0x76, 0x5f, 0x63,
+	0x6c, 0x69, 0x63, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x61, 0x76, 0x43,
+	0x6c, 0x69, 0x63, 0x6b, 0x22, 0xfd, 0x03, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x4c, 0x61, 0x79,
+	0x6f, 0x75, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x18, 0x09, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x44, 0x61, 0x74,
+	0x61, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x54,
+	0x61, 0x62, 0x6c, 0x65, 0x52, 0x06, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x08,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x43, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x52, 0x08, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73, 0x12, 0x33,
+	0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x73, 0x12, 0x4c, 0x0a, 0x10, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f,
+	0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e,
+	0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x4c, 0x61, 0x79, 0x6f, 0x75,
+	0x74, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73,
+	0x1a, 0x9c, 0x01, 0x0a, 0x0c, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x54, 0x61, 0x62, 0x6c,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x5f, 0x69, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x66,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x5f,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x66,
+	0x54, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x38, 0x0a, 0x0a, 0x72, 0x6f, 0x77, 0x5f, 0x66, 0x69, 0x6c,
+	0x74, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6c, 0x79, 0x6e, 0x6b,
+	0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x46, 0x69,
+	0x6c, 0x74, 0x65, 0x72, 0x52, 0x09, 0x72, 0x6f, 0x77, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x1a,
+	0x43, 0x0a, 0x0e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f,
+	0x75, 0x74, 0x4d, 0x73, 0x22, 0xcd, 0x03, 0x0a, 0x0b, 0x44, 0x61, 0x74, 0x61, 0x6c, 0x65, 0x74,
+	0x53, 0x70, 0x65, 0x63, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61,
+	0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x28, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e,
+	0x44, 0x61, 0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x12, 0x31, 0x0a, 0x0a, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x73, 0x70, 0x65, 0x63, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x54,
+	0x61, 0x62, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x53,
+	0x70, 0x65, 0x63, 0x12, 0x32, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1e, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x6c,
+	0x65, 0x74, 0x53, 0x70, 0x65, 0x63, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x1a, 0x22, 0x0a, 0x0c, 0x44, 0x69, 0x73, 0x70, 0x6c,
+	0x61, 0x79, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x1a, 0xb6, 0x01, 0x0a, 0x0a,
+	0x4c, 0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x69,
+	0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0d, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x73, 0x12, 0x31, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x19, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61,
 	0x51, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69,
-	0x6c, 0x74, 0x65, 0x72, 0x12, 0x28, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x08, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61,
-	0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x31,
-	0x0a, 0x0a, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x73, 0x70, 0x65, 0x63, 0x18, 0x0a, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x61, 0x62,
-	0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x70, 0x65,
-	0x63, 0x12, 0x32, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1e, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x6c, 0x65, 0x74,
-	0x53, 0x70, 0x65, 0x63, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x04, 0x6c, 0x69, 0x73, 0x74, 0x1a, 0x22, 0x0a, 0x0c, 0x44, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79,
-	0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x1a, 0x99, 0x01, 0x0a, 0x0a, 0x4c, 0x69,
-	0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x69, 0x73, 0x70,
-	0x6c, 0x61, 0x79, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x0d, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12,
-	0x31, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x19, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x51, 0x75,
-	0x65, 0x72, 0x79, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74,
-	0x65, 0x72, 0x12, 0x31, 0x0a, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1d, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x51,
-	0x75, 0x65, 0x72, 0x79, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52,
-	0x04, 0x73, 0x6f, 0x72, 0x74, 0x22, 0xbc, 0x01, 0x0a, 0x0c, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61,
-	0x74, 0x65, 0x53, 0x70, 0x65, 0x63, 0x12, 0x2e, 0x0a, 0x06, 0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e,
-	0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x06,
-	0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x25, 0x0a, 0x03, 0x6e, 0x61, 0x76, 0x18, 0x0a, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d,
-	0x70, 0x6c, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x76, 0x52, 0x03, 0x6e, 0x61, 0x76, 0x12, 0x2b, 0x0a,
-	0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6c,
-	0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61,
-	0x62, 0x6c, 0x65, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x68, 0x74,
-	0x6d, 0x6c, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75,
-	0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x48, 0x74, 0x6d, 0x6c, 0x52, 0x04,
-	0x68, 0x74, 0x6d, 0x6c, 0x22, 0xdc, 0x02, 0x0a, 0x0e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74,
-	0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61,
-	0x6c, 0x69, 0x67, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x67,
-	0x6e, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
-	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
-	0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x0c,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73,
-	0x12, 0x3d, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x23, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c,
-	0x61, 0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
-	0x2a, 0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e,
-	0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c,
-	0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x2a, 0x0a, 0x04, 0x63,
-	0x6f, 0x6c, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x79, 0x6e, 0x6b,
-	0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75,
-	0x74, 0x52, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x4f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
-	0x02, 0x38, 0x01, 0x22, 0x89, 0x01, 0x0a, 0x0b, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
-	0x4e, 0x61, 0x76, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x2e, 0x0a,
-	0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c,
-	0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4e, 0x61,
-	0x76, 0x2e, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x1a, 0x30, 0x0a,
-	0x04, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74,
-	0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x22,
-	0x36, 0x0a, 0x0c, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x48, 0x74, 0x6d, 0x6c, 0x12,
-	0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66,
-	0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x22, 0x0f, 0x0a, 0x0d, 0x54, 0x65, 0x6d, 0x70, 0x6c,
-	0x61, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x2d, 0x48, 0x03, 0x5a, 0x29, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x79, 0x6e, 0x6b, 0x64, 0x62, 0x2f,
-	0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2f, 0x67, 0x6f, 0x2f, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69,
-	0x3b, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6c, 0x74, 0x65, 0x72, 0x12, 0x31, 0x0a, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x61, 0x74,
+	0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x52, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f,
+	0x73, 0x69, 0x7a, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65,
+	0x53, 0x69, 0x7a, 0x65, 0x22, 0xbc, 0x01, 0x0a, 0x0c, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74,
+	0x65, 0x53, 0x70, 0x65, 0x63, 0x12, 0x2e, 0x0a, 0x06, 0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54,
+	0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x06, 0x6c,
+	0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x25, 0x0a, 0x03, 0x6e, 0x61, 0x76, 0x18, 0x0a, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70,
+	0x6c, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x76, 0x52, 0x03, 0x6e, 0x61, 0x76, 0x12, 0x2b, 0x0a, 0x05,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6c, 0x79,
+	0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x62,
+	0x6c, 0x65, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x68, 0x74, 0x6d,
+	0x6c, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69,
+	0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x48, 0x74, 0x6d, 0x6c, 0x52, 0x04, 0x68,
+	0x74, 0x6d, 0x6c, 0x22, 0xdc, 0x02, 0x0a, 0x0e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c,
+	0x69, 0x67, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x67, 0x6e,
+	0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1f,
+	0x0a, 0x0b, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12,
+	0x3d, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x23, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2a,
+	0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c,
+	0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x61,
+	0x79, 0x6f, 0x75, 0x74, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x2a, 0x0a, 0x04, 0x63, 0x6f,
+	0x6c, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x79, 0x6e, 0x6b, 0x75,
+	0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x61, 0x79, 0x6f, 0x75, 0x74,
+	0x52, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x89, 0x01, 0x0a, 0x0b, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4e,
+	0x61, 0x76, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x2e, 0x0a, 0x05,
+	0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c, 0x79,
+	0x6e, 0x6b, 0x75, 0x69, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x76,
+	0x2e, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x1a, 0x30, 0x0a, 0x04,
+	0x49, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x22, 0x36,
+	0x0a, 0x0c, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x48, 0x74, 0x6d, 0x6c, 0x12, 0x12,
+	0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69,
+	0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x68, 0x74, 0x6d, 0x6c, 0x22, 0x0f, 0x0a, 0x0d, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x2d, 0x48, 0x03, 0x5a, 0x29, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x79, 0x6e, 0x6b, 0x64, 0x62, 0x2f, 0x6c,
+	0x79, 0x6e, 0x6b, 0x75, 0x69, 0x2f, 0x67, 0x6f, 0x2f, 0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x3b,
+	0x6c, 0x79, 0x6e, 0x6b, 0x75, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1231,7 +1350,7 @@ func file_lynkui_lynkui_proto_rawDescGZIP() []byte {
 	return file_lynkui_lynkui_proto_rawDescData
 }
 
-var file_lynkui_lynkui_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_lynkui_lynkui_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_lynkui_lynkui_proto_goTypes = []interface{}{
 	(*Project)(nil),                      // 0: lynkui.Project
 	(*Pagelet)(nil),                      // 1: lynkui.Pagelet
@@ -1247,16 +1366,17 @@ var file_lynkui_lynkui_proto_goTypes = []interface{}{
 	(*Pagelet_Next)(nil),                 // 11: lynkui.Pagelet.Next
 	(*Pagelet_Event)(nil),                // 12: lynkui.Pagelet.Event
 	(*DataLayout_VirtualTable)(nil),      // 13: lynkui.DataLayout.VirtualTable
-	(*DataletSpec_DisplayField)(nil),     // 14: lynkui.DataletSpec.DisplayField
-	(*DataletSpec_ListAction)(nil),       // 15: lynkui.DataletSpec.ListAction
-	nil,                                  // 16: lynkui.TemplateLayout.OptionsEntry
-	(*TemplateNav_Item)(nil),             // 17: lynkui.TemplateNav.Item
-	(*lynkapi.DataConnect)(nil),          // 18: lynkapi.DataConnect
-	(*lynkapi.DataInstance)(nil),         // 19: lynkapi.DataInstance
-	(*lynkapi.DataQuery_Filter)(nil),     // 20: lynkapi.DataQuery.Filter
-	(*lynkapi.DataQuery)(nil),            // 21: lynkapi.DataQuery
-	(*lynkapi.TableSpec)(nil),            // 22: lynkapi.TableSpec
-	(*lynkapi.DataQuery_SortFilter)(nil), // 23: lynkapi.DataQuery.SortFilter
+	(*DataLayout_InstanceOption)(nil),    // 14: lynkui.DataLayout.InstanceOption
+	(*DataletSpec_DisplayField)(nil),     // 15: lynkui.DataletSpec.DisplayField
+	(*DataletSpec_ListAction)(nil),       // 16: lynkui.DataletSpec.ListAction
+	nil,                                  // 17: lynkui.TemplateLayout.OptionsEntry
+	(*TemplateNav_Item)(nil),             // 18: lynkui.TemplateNav.Item
+	(*lynkapi.DataConnect)(nil),          // 19: lynkapi.DataConnect
+	(*lynkapi.DataInstance)(nil),         // 20: lynkapi.DataInstance
+	(*lynkapi.DataQuery_Filter)(nil),     // 21: lynkapi.DataQuery.Filter
+	(*lynkapi.DataQuery)(nil),            // 22: lynkapi.DataQuery
+	(*lynkapi.TableSpec)(nil),            // 23: lynkapi.TableSpec
+	(*lynkapi.DataQuery_SortFilter)(nil), // 24: lynkapi.DataQuery.SortFilter
 }
 var file_lynkui_lynkui_proto_depIdxs = []int32{
 	10, // 0: lynkui.Pagelet.args:type_name -> lynkui.Pagelet.ArgsEntry
@@ -1266,27 +1386,29 @@ var file_lynkui_lynkui_proto_depIdxs = []int32{
 	12, // 4: lynkui.Pagelet.event:type_name -> lynkui.Pagelet.Event
 	2,  // 5: lynkui.Pagelet.post_tasklets:type_name -> lynkui.Tasklet
 	13, // 6: lynkui.DataLayout.tables:type_name -> lynkui.DataLayout.VirtualTable
-	18, // 7: lynkui.DataLayout.connects:type_name -> lynkapi.DataConnect
-	19, // 8: lynkui.DataLayout.instances:type_name -> lynkapi.DataInstance
-	20, // 9: lynkui.DataletSpec.filter:type_name -> lynkapi.DataQuery.Filter
-	21, // 10: lynkui.DataletSpec.query:type_name -> lynkapi.DataQuery
-	22, // 11: lynkui.DataletSpec.table_spec:type_name -> lynkapi.TableSpec
-	15, // 12: lynkui.DataletSpec.list:type_name -> lynkui.DataletSpec.ListAction
-	6,  // 13: lynkui.TemplateSpec.layout:type_name -> lynkui.TemplateLayout
-	7,  // 14: lynkui.TemplateSpec.nav:type_name -> lynkui.TemplateNav
-	9,  // 15: lynkui.TemplateSpec.table:type_name -> lynkui.TemplateTable
-	8,  // 16: lynkui.TemplateSpec.html:type_name -> lynkui.TemplateHtml
-	16, // 17: lynkui.TemplateLayout.options:type_name -> lynkui.TemplateLayout.OptionsEntry
-	6,  // 18: lynkui.TemplateLayout.rows:type_name -> lynkui.TemplateLayout
-	6,  // 19: lynkui.TemplateLayout.cols:type_name -> lynkui.TemplateLayout
-	17, // 20: lynkui.TemplateNav.items:type_name -> lynkui.TemplateNav.Item
-	20, // 21: lynkui.DataletSpec.ListAction.filter:type_name -> lynkapi.DataQuery.Filter
-	23, // 22: lynkui.DataletSpec.ListAction.sort:type_name -> lynkapi.DataQuery.SortFilter
-	23, // [23:23] is the sub-list for method output_type
-	23, // [23:23] is the sub-list for method input_type
-	23, // [23:23] is the sub-list for extension type_name
-	23, // [23:23] is the sub-list for extension extendee
-	0,  // [0:23] is the sub-list for field type_name
+	19, // 7: lynkui.DataLayout.connects:type_name -> lynkapi.DataConnect
+	20, // 8: lynkui.DataLayout.instances:type_name -> lynkapi.DataInstance
+	14, // 9: lynkui.DataLayout.instance_options:type_name -> lynkui.DataLayout.InstanceOption
+	21, // 10: lynkui.DataletSpec.filter:type_name -> lynkapi.DataQuery.Filter
+	22, // 11: lynkui.DataletSpec.query:type_name -> lynkapi.DataQuery
+	23, // 12: lynkui.DataletSpec.table_spec:type_name -> lynkapi.TableSpec
+	16, // 13: lynkui.DataletSpec.list:type_name -> lynkui.DataletSpec.ListAction
+	6,  // 14: lynkui.TemplateSpec.layout:type_name -> lynkui.TemplateLayout
+	7,  // 15: lynkui.TemplateSpec.nav:type_name -> lynkui.TemplateNav
+	9,  // 16: lynkui.TemplateSpec.table:type_name -> lynkui.TemplateTable
+	8,  // 17: lynkui.TemplateSpec.html:type_name -> lynkui.TemplateHtml
+	17, // 18: lynkui.TemplateLayout.options:type_name -> lynkui.TemplateLayout.OptionsEntry
+	6,  // 19: lynkui.TemplateLayout.rows:type_name -> lynkui.TemplateLayout
+	6,  // 20: lynkui.TemplateLayout.cols:type_name -> lynkui.TemplateLayout
+	18, // 21: lynkui.TemplateNav.items:type_name -> lynkui.TemplateNav.Item
+	21, // 22: lynkui.DataLayout.VirtualTable.row_filter:type_name -> lynkapi.DataQuery.Filter
+	21, // 23: lynkui.DataletSpec.ListAction.filter:type_name -> lynkapi.DataQuery.Filter
+	24, // 24: lynkui.DataletSpec.ListAction.sort:type_name -> lynkapi.DataQuery.SortFilter
+	25, // [25:25] is the sub-list for method output_type
+	25, // [25:25] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_lynkui_lynkui_proto_init() }
@@ -1452,7 +1574,7 @@ func file_lynkui_lynkui_proto_init() {
 			}
 		}
 		file_lynkui_lynkui_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataletSpec_DisplayField); i {
+			switch v := v.(*DataLayout_InstanceOption); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1464,6 +1586,18 @@ func file_lynkui_lynkui_proto_init() {
 			}
 		}
 		file_lynkui_lynkui_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DataletSpec_DisplayField); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lynkui_lynkui_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DataletSpec_ListAction); i {
 			case 0:
 				return &v.state
@@ -1475,7 +1609,7 @@ func file_lynkui_lynkui_proto_init() {
 				return nil
 			}
 		}
-		file_lynkui_lynkui_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+		file_lynkui_lynkui_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TemplateNav_Item); i {
 			case 0:
 				return &v.state
@@ -1494,7 +1628,7 @@ func file_lynkui_lynkui_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_lynkui_lynkui_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   18,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -14,6 +14,12 @@
 
 package lynkui
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 func (it *TemplateLayout) Refix() *TemplateLayout {
 	if it.Width == "" {
 		it.Width = "auto"
@@ -28,3 +34,51 @@ func (it *TemplateLayout) Refix() *TemplateLayout {
 	}
 	return it
 }
+
+var templateIdentRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]{0,99}$`)
+
+// TemplateIdentValid reports whether the name may be used in an HTML id or
+// class attribute.
+func TemplateIdentValid(s string) bool {
+	return templateIdentRx.MatchString(s)
+}
+
+// StyleClasses returns the classes of StyleClass, separated by commas or
+// spaces.
+func (it *TemplateLayout) StyleClasses() []string {
+	return strings.FieldsFunc(it.StyleClass, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// Validate checks the names and the style classes of the layout and of its
+// rows and columns, they are written to the id and class attributes.
+func (it *TemplateLayout) Validate() error {
+	if it.Name != "" && !TemplateIdentValid(it.Name) {
+		return fmt.Errorf("invalid layout name (%s)", it.Name)
+	}
+	for _, v := range it.StyleClasses() {
+		if !TemplateIdentValid(v) {
+			return fmt.Errorf("invalid style class (%s) of layout (%s)", v, it.Name)
+		}
+	}
+	for _, ls := range [][]*TemplateLayout{it.Rows, it.Cols} {
+		for _, v := range ls {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the display of the nav, it is written to the class
+// attribute.
+func (it *TemplateNav) Validate() error {
+	switch it.Display {
+	case "", "flex-column":
+	default:
+		return fmt.Errorf("invalid nav display (%s)", it.Display)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/audit"
+)
+
+const (
+	appAuditFile = "lynkui_audit.jsonl"
+
+	// the built-in pagelet browsing the audit trail, a project may define
+	// its own pagelet of this name, e.g. to render it into another output
+	auditPageletName = "lynk_audit"
+)
+
+func auditPagelet() *lynkui.Pagelet {
+	return &lynkui.Pagelet{
+		Kind:        "Pagelet",
+		Name:        auditPageletName,
+		DisplayName: "Audit Log",
+		Output:      "main",
+		Template: &lynkui.TemplateSpec{
+			Html: &lynkui.TemplateHtml{
+				File: "core/v1/block-table-list.html",
+			},
+		},
+		Datalet: &lynkui.DataletSpec{
+			TableName: audit.TableName,
+			List: &lynkui.DataletSpec_ListAction{
+				DisplayFields: []string{"created", "user", "op", "pagelet", "table_name", "pk"},
+				Sort: &lynkapi.DataQuery_SortFilter{
+					Field: "created",
+					Type:  "desc",
+				},
+				PageSize: 50,
+			},
+		},
+	}
+}
+
+func (it *serviceImpl) auditSetup() error {
+
+	file := it.cfg.AppProjectPath + "/" + appAuditFile
+	if it.cfg.AppProjectReadOnly {
+		// kept in memory, as the data changes are
+		file = ""
+	}
+
+	it.audit = audit.NewLog(file, it.cfg.AuditService)
+
+	return it.layout.RegisterService(it.audit)
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const (
+	appUsersFile  = "lynkui_users.json"
+	appAccessFile = "lynkui_access.json"
+
+	passwordHashName = "pbkdf2-sha256"
+	passwordHashIter = 600000
+	passwordSaltLen  = 16
+	passwordKeyLen   = 32
+)
+
+type localUser struct {
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	Roles    []string          `json:"roles,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+}
+
+type localUserSet struct {
+	Users []*localUser `json:"users"`
+}
+
+// localAuthenticator verifies logins against the users file of the project,
+// the file is read on each login so that changes apply without a restart.
+type localAuthenticator struct {
+	file string
+}
+
+var (
+	passwordDummyOnce sync.Once
+	passwordDummy     string
+)
+
+func (it *localAuthenticator) Authenticate(name, password string) (*lynkui.User, error) {
+
+	b, err := os.ReadFile(it.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var set localUserSet
+	if err := codec.Json.Decode(b, &set); err != nil {
+		return nil, fmt.Errorf("%s : %s", appUsersFile, err.Error())
+	}
+
+	for _, u := range set.Users {
+		if u.Name != "" && u.Name == name {
+			if !passwordVerify(u.Password, password) {
+				break
+			}
+			return &lynkui.User{
+				Name:  u.Name,
+				Roles: u.Roles,
+				Attrs: u.Attrs,
+			}, nil
+		}
+	}
+
+	// spend the same time on unknown names as on wrong passwords
+	passwordDummyOnce.Do(func() {
+		passwordDummy, _ = PasswordHash("")
+	})
+	passwordVerify(passwordDummy, password)
+
+	return nil, fmt.Errorf("invalid name or password")
+}
+
+// PasswordHash returns the hash of the password in the format of the
+// password field of lynkui_users.json.
+func PasswordHash(password string) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIter, passwordKeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", passwordHashName, passwordHashIter,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func passwordVerify(hash, password string) bool {
+	ar := strings.Split(hash, "$")
+	if len(ar) != 4 || ar[0] != passwordHashName {
+		return false
+	}
+	iter, err := strconv.Atoi(ar[1])
+	if err != nil || iter < 1 || iter > 10*passwordHashIter {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(ar[2])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(ar[3])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	key2, err := pbkdf2.Key(sha256.New, password, salt, iter, len(key))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, key2) == 1
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+func pageletEncode(item *lynkui.Pagelet) []byte {
+	js, _ := codec.Json.Encode(item, &codec.JsonOptions{
+		Width: 120,
+	})
+	return js
+}
+
+// FormatProject rewrites the JSON pagelet, layout and data files of the
+// project in canonical form, and returns the relpaths of the changed files.
+func FormatProject(projPath string) ([]string, error) {
+
+	projPath, err := filepath.Abs(projPath)
+	if err != nil {
+		return nil, err
+	}
+	projPath = filepath.Clean(projPath)
+
+	var (
+		changes []string
+		rewrite = func(relpath string, js []byte) error {
+			b, err := os.ReadFile(projPath + "/" + relpath)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(b, js) {
+				return nil
+			}
+			if err = os.WriteFile(projPath+"/"+relpath, js, 0640); err != nil {
+				return err
+			}
+			changes = append(changes, relpath)
+			return nil
+		}
+	)
+
+	for _, v := range []struct {
+		relpath string
+		obj     interface{}
+	}{
+		{"lynkui_layout.json", &lynkui.DataLayout{}},
+		{"lynkui_data.json", &lynkui.MainObjectSet{}},
+	} {
+		b, err := os.ReadFile(projPath + "/" + v.relpath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return changes, err
+		}
+		if err = codec.Json.Decode(b, v.obj); err != nil {
+			return changes, &lynkui.ValidateError{
+				File:    v.relpath,
+				Message: err.Error(),
+			}
+		}
+		js, _ := codec.Json.Encode(v.obj, &codec.JsonOptions{
+			Width: 120,
+		})
+		if err = rewrite(v.relpath, js); err != nil {
+			return changes, err
+		}
+	}
+
+	err = filepath.Walk(projPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || len(path) <= len(projPath) {
+			return nil
+		}
+		relpath := path[len(projPath)+1:]
+		if !appPageletFileRx.MatchString(relpath) ||
+			filepath.Ext(relpath) != ".json" {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var item lynkui.Pagelet
+		if err = json.Unmarshal(b, &item); err != nil {
+			return &lynkui.ValidateError{
+				File:    relpath,
+				Message: err.Error(),
+			}
+		}
+		if mat := appPageletFileRx.FindStringSubmatch(relpath); len(mat) == 3 {
+			item.Name = mat[1]
+		}
+		return rewrite(relpath, pageletEncode(&item))
+	})
+
+	return changes, err
+}
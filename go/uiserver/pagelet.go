@@ -0,0 +1,57 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hooto/htoml4g/htoml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+// pageletDecode decodes a pagelet file by its extension. YAML and TOML
+// files are converted to JSON first, so that the nested lynkapi messages
+// (e.g. the structpb values of filters) decode the same way in all formats.
+func pageletDecode(ext string, b []byte, item *lynkui.Pagelet) error {
+
+	var obj map[string]interface{}
+
+	switch ext {
+	case ".json":
+		return json.Unmarshal(b, item)
+
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &obj); err != nil {
+			return err
+		}
+
+	case ".toml":
+		if err := htoml.Decode(b, &obj); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown pagelet format (%s)", ext)
+	}
+
+	js, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, item)
+}
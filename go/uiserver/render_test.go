@@ -0,0 +1,119 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testUpdate = flag.Bool("update", false, "update the golden files of testdata")
+
+// TestServerRenderGolden compares the server rendered pages of a project
+// with a layout, a nav and a table pagelet with the files of
+// testdata/render. Run with -update to rewrite them.
+func TestServerRenderGolden(t *testing.T) {
+
+	_, base := testService(t, map[string]string{
+		"lynkui_data.json": `{"lynk_dict": [
+			{"id": "m1", "ns": "menu", "name": "red", "display_name": "Red <1>", "order": 1},
+			{"id": "m2", "ns": "menu", "name": "blue", "order": 2},
+			{"id": "c1", "ns": "color", "name": "c1", "display_name": "a & b", "order": 1},
+			{"id": "c2", "ns": "color", "name": "c2", "order": 2},
+			{"id": "c3", "ns": "color", "name": "c3", "order": 3}
+		]}`,
+		"pagelet/index.json": `{
+			"kind": "Pagelet",
+			"output": "body-content",
+			"template": {"layout": {"cols": [
+				{"name": "nav", "width": "200px", "style_class": "border-end"},
+				{"name": "main", "width": "auto"}
+			]}},
+			"next_pagelets": [{"name": "menu"}, {"name": "dict"}]
+		}`,
+		"pagelet/menu.json": `{
+			"kind": "Pagelet",
+			"output": "nav",
+			"template": {"nav": {"display": "flex-column"}},
+			"datalet": {
+				"table_name": "lynk_dict",
+				"filter": {"field": "ns", "value": "menu"},
+				"list": {"sort": {"field": "order"}}
+			},
+			"event": {"name": "onclick", "pagelet": "dict"}
+		}`,
+		"pagelet/dict.json": `{
+			"kind": "Pagelet",
+			"display_name": "Colors",
+			"output": "main",
+			"template": {"html": {"file": "core/v1/block-table-list.html"}},
+			"datalet": {
+				"table_name": "lynk_dict",
+				"filter": {"field": "ns", "value": "color"},
+				"list": {
+					"display_fields": ["name", "display_name", "order"],
+					"sort": {"field": "order"},
+					"page_size": 2
+				}
+			}
+		}`,
+	})
+
+	entry := strings.TrimSuffix(base, "/api/v1")
+
+	for name, query := range map[string]string{
+		"index":  "",
+		"sort":   "&sort.dict=name:desc",
+		"offset": "&offset.dict=2",
+		"event":  "&event=menu&dict_id=m1",
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			rsp, err := http.Get(entry + "/?render=server" + query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rsp.Body.Close()
+
+			body, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the entry path has the port of the test service
+			got := strings.ReplaceAll(string(body), entry[strings.LastIndexByte(entry, '/'):], "/lynkui")
+
+			file := filepath.Join("testdata", "render", name+".html")
+			if *testUpdate {
+				if err := os.WriteFile(file, []byte(got), 0640); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("render of %s differs from %s :\n%s", query, file, got)
+			}
+		})
+	}
+}
@@ -19,10 +19,12 @@ import (
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -34,6 +36,8 @@ import (
 	"github.com/lynkdb/lynkapi/go/lynkapi"
 	"github.com/lynkdb/lynkapi/go/oneobject"
 
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/audit"
 	"github.com/lynkdb/lynkui/internal/bindata"
 	"github.com/lynkdb/lynkui/internal/data"
 	"github.com/lynkdb/lynkui/internal/status"
@@ -47,29 +51,36 @@ type Service interface {
 	DataLayout() data.DataService
 
 	AssetsHandler() http.Handler
+
+	// Close stops the background checks of the remote instances.
+	Close() error
 }
 
 type serviceImpl struct {
 	cfg lynkui.ServiceConfig
 
 	mainDataService lynkapi.DataService
+
+	layout *data.LayoutManager
+	assets *status.Sets
+	access *access.Manager
+	audit  *audit.Log
 }
 
 var (
 	appTemplateFileRx = regexp.MustCompile(`template\/(.*)(\.html)$`)
-	appPageletFileRx  = regexp.MustCompile(`pagelet\/(.*)(\.json)$`)
+	appPageletFileRx  = regexp.MustCompile(`pagelet\/(.*)(\.json|\.yaml|\.yml|\.toml)$`)
 
 	coreTemplateFileRx = regexp.MustCompile(`lynkui\/tpl\/(.*)(\.html)$`)
-
-	service = &serviceImpl{}
-
-	err error
 )
 
 func NewAssetsFs() http.FileSystem {
 	return bindata.Assets
 }
 
+// NewService sets up an independent project service, with its own data
+// layout and asset registry. Several services may be mounted on the same
+// httpsrv.Service as long as their UrlEntryPath differ.
 func NewService(s *httpsrv.Service, cfg *lynkui.ServiceConfig) (Service, error) {
 
 	if cfg.UrlEntryPath == "" {
@@ -105,7 +116,19 @@ func NewService(s *httpsrv.Service, cfg *lynkui.ServiceConfig) (Service, error)
 		}
 	}
 
-	service.cfg = *cfg
+	service := &serviceImpl{
+		cfg:    *cfg,
+		layout: data.NewLayoutManager(),
+		assets: status.NewSets(),
+		access: access.NewManager(),
+	}
+
+	ok := false
+	defer func() {
+		if !ok {
+			service.layout.Close()
+		}
+	}()
 
 	if err := service.init(); err != nil {
 		return nil, err
@@ -117,6 +140,7 @@ func NewService(s *httpsrv.Service, cfg *lynkui.ServiceConfig) (Service, error)
 	if err := service.appAssetsRefresh(); err != nil {
 		return nil, err
 	}
+	service.assets.SetBuiltinPagelet(auditPageletName, auditPagelet())
 
 	if cfg.RunMode == "dev" {
 		if err := service.coreAssetsRefresh(); err != nil {
@@ -124,12 +148,42 @@ func NewService(s *httpsrv.Service, cfg *lynkui.ServiceConfig) (Service, error)
 		}
 	}
 
+	if service.cfg.Authenticator == nil {
+		file := service.cfg.AppProjectPath + "/" + appUsersFile
+		if _, err := os.Stat(file); err == nil {
+			service.cfg.Authenticator = &localAuthenticator{
+				file: file,
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if service.cfg.Authenticator == nil {
+		hlog.Printf("warn", "project %s : no authenticator setup, the console is open to everyone",
+			service.cfg.AppProjectPath)
+	}
+
+	if err := service.access.Load(service.cfg.AppProjectPath + "/" + appAccessFile); err != nil {
+		return nil, fmt.Errorf("%s : %s", appAccessFile, err.Error())
+	}
+	if service.access.Enabled() && service.cfg.Authenticator == nil {
+		hlog.Printf("warn", "project %s : %s is not enforced without an authenticator",
+			service.cfg.AppProjectPath, appAccessFile)
+	}
+
 	if s != nil {
-		if err := websrv.Setup(s, cfg); err != nil {
+		if err := websrv.Setup(s, &websrv.Host{
+			Config: &service.cfg,
+			Layout: service.layout,
+			Assets: service.assets,
+			Access: service.access,
+			Audit:  service.audit,
+		}); err != nil {
 			return nil, err
 		}
 	}
 
+	ok = true
 	return service, nil
 }
 
@@ -137,7 +191,7 @@ func (it *serviceImpl) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
 	relpath := filepath.Clean(req.URL.Path)
 	if strings.HasPrefix(relpath, "/lynkui/template/") {
 
-		if tpl := status.Assets.Get(relpath[len("/lynkui/"):]); tpl != nil {
+		if tpl := it.assets.Get(relpath[len("/lynkui/"):]); tpl != nil {
 			if h, ok := tpl.(*lynkui.TemplateHtml); ok {
 				js, _ := json.Marshal(h)
 				wr.Header().Set("Content-Type", "application/json")
@@ -153,26 +207,51 @@ func (it *serviceImpl) AssetsHandler() http.Handler {
 	return it
 }
 
+func (it *serviceImpl) Close() error {
+	return it.layout.Close()
+}
+
 func (it *serviceImpl) MainDataService() lynkapi.DataService {
 	return it.mainDataService
 }
 
 func (it *serviceImpl) DataLayout() data.DataService {
-	return data.Layout
+	return it.layout
 }
 
 func (it *serviceImpl) init() error {
 
-	if err := data.Init(it.cfg.AppProjectPath + "/lynkui_layout.json"); err != nil {
+	if err := it.layout.Init(it.cfg.AppProjectPath+"/lynkui_layout.json", it.cfg.AppProjectReadOnly); err != nil {
 		if os.IsNotExist(err) {
 			return nil
 		}
 		return err
 	}
 
-	var do lynkui.MainObjectSet
+	if err := it.auditSetup(); err != nil {
+		return err
+	}
 
-	inst, err := oneobject.NewInstanceFromFile("lynkui", it.cfg.AppProjectPath+"/lynkui_data.json", &do)
+	var (
+		do   lynkui.MainObjectSet
+		inst *oneobject.Instance
+		file = it.cfg.AppProjectPath + "/lynkui_data.json"
+		err  error
+	)
+
+	if it.cfg.AppProjectReadOnly {
+		// without a file bound the instance never flushes
+		if b, err := os.ReadFile(file); err == nil {
+			if err = codec.Json.Decode(b, &do); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		inst, err = oneobject.NewInstance("lynkui", &do)
+	} else {
+		inst, err = oneobject.NewInstanceFromFile("lynkui", file, &do)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -204,7 +283,7 @@ func (it *serviceImpl) init() error {
 
 	inst.Flush()
 
-	if err = data.Layout.RegisterService(inst); err != nil {
+	if err = it.layout.RegisterService(inst); err != nil {
 		return err
 	}
 
@@ -241,7 +320,7 @@ func (it *serviceImpl) coreAssetsRefresh() error {
 		}
 		asfs.WriteFile(relpath, b)
 
-		status.Assets.Sync(relpath, &lynkui.TemplateHtml{
+		it.assets.Sync(relpath, &lynkui.TemplateHtml{
 			File: relpath,
 			Html: string(b),
 		})
@@ -316,31 +395,118 @@ func (it *serviceImpl) coreAssetsRefresh() error {
 
 func (it *serviceImpl) appAssetsRefresh() error {
 
+	var (
+		// relpath of registered pagelet file -> pagelet name
+		pagelets = map[string]string{}
+
+		// pagelets parsed by the initial walk, validated once all project
+		// files are known so that cross references can be resolved
+		pending map[string]*lynkui.Pagelet
+	)
+
+	validator := &pageletValidator{
+		tableExists: it.layout.HasTable,
+		pageletExists: func(name string) bool {
+			if name == auditPageletName {
+				return true
+			}
+			for _, v := range pagelets {
+				if v == name {
+					return true
+				}
+			}
+			// the files of the initial walk may refer to each other
+			for _, v := range pending {
+				if v.Name == name {
+					return true
+				}
+			}
+			return false
+		},
+		templateExists: func(file string) bool {
+			return coreTemplateExists(file) ||
+				it.assets.Get("lynkui/tpl/"+file) != nil ||
+				it.assets.Get("template/"+file) != nil
+		},
+	}
+
+	// store registers the pagelet if it is valid, otherwise the last-good
+	// version (if any) is kept and the errors are reported.
+	store := func(path, relpath string, item *lynkui.Pagelet) {
+
+		if errs := validator.validate(relpath, item); len(errs) > 0 {
+			it.assets.SetErrors(relpath, errs)
+			for _, e := range errs {
+				hlog.Printf("warn", "asset %s, validate err %s", relpath, e.Error())
+			}
+			return
+		}
+		it.assets.SetErrors(relpath, nil)
+
+		hlog.Printf("info", "asset %s, name %v", relpath, item.Name)
+		pagelets[relpath] = item.Name
+		it.assets.SetPagelet(item.Name, item)
+
+		// only JSON files are canonicalized, YAML and TOML files keep
+		// their comments
+		if it.cfg.AppProjectReadOnly || filepath.Ext(relpath) != ".json" {
+			return
+		}
+
+		if err := ioutil.WriteFile(path, pageletEncode(item), 0640); err == nil {
+			hlog.Printf("warn", "asset %s, flush ok", relpath)
+		} else {
+			hlog.Printf("warn", "asset %s, flush fail %s", relpath, err.Error())
+		}
+	}
+
 	load := func(path string) error {
 
 		var (
-			obj     interface{}
 			relpath = path[len(it.cfg.AppProjectPath)+1:]
 		)
 
 		switch {
 
 		case appPageletFileRx.MatchString(relpath):
+			mat := appPageletFileRx.FindStringSubmatch(relpath)
+			if len(mat) != 3 {
+				return nil
+			}
+			defined := maps.Clone(pagelets)
+			for fpath, item := range pending {
+				defined[fpath] = item.Name
+			}
+			for fpath, name := range defined {
+				if name == mat[1] && fpath != relpath {
+					hlog.Printf("warn", "asset %s, pagelet %s already defined in %s", relpath, name, fpath)
+					it.assets.SetErrors(relpath, []*lynkui.ValidateError{{
+						File:    relpath,
+						Pagelet: mat[1],
+						Message: fmt.Sprintf("pagelet already defined in %s", fpath),
+					}})
+					return nil
+				}
+			}
 			var item lynkui.Pagelet
 			b, err := os.ReadFile(path)
 			if err != nil {
 				return err
 			}
-			if err = json.Unmarshal(b, &item); err == nil {
-				if mat := appPageletFileRx.FindStringSubmatch(relpath); len(mat) == 3 {
-					hlog.Printf("info", "asset %s, name %v", relpath, mat[1])
-					item.Name = mat[1]
-					status.Assets.SetPagelet(mat[1], &item)
-					obj = item
-				}
-
-			} else {
+			if err = pageletDecode(mat[2], b, &item); err != nil {
 				hlog.Printf("warn", "asset %s, err %s", relpath, err.Error())
+				it.assets.SetErrors(relpath, []*lynkui.ValidateError{{
+					File:    relpath,
+					Pagelet: mat[1],
+					Message: err.Error(),
+				}})
+				return nil
+			}
+			item.Name = mat[1]
+			if pending != nil {
+				pending[relpath] = &item
+			} else {
+				store(path, relpath, &item)
 			}
 
 		case appTemplateFileRx.MatchString(path):
@@ -348,28 +514,51 @@ func (it *serviceImpl) appAssetsRefresh() error {
 			if err != nil {
 				return err
 			}
-			status.Assets.Sync(relpath, &lynkui.TemplateHtml{
+			it.assets.Sync(relpath, &lynkui.TemplateHtml{
 				File: relpath,
 				Html: string(b),
 			})
 			hlog.Printf("info", "asset %s", relpath)
+		}
 
-		default:
-			return nil
+		return nil
+	}
+
+	// unload evicts the pagelets and templates loaded from the path,
+	// or from any file under it if the path was a directory.
+	unload := func(path string) {
+
+		if len(path) <= len(it.cfg.AppProjectPath) {
+			return
 		}
 
-		if obj != nil {
-			js, _ := codec.Json.Encode(obj, &codec.JsonOptions{
-				Width: 120,
-			})
-			if err := ioutil.WriteFile(path, js, 0640); err == nil {
-				hlog.Printf("warn", "asset %s, flush ok", relpath)
-			} else {
-				hlog.Printf("warn", "asset %s, flush fail %s", relpath, err.Error())
+		var (
+			relpath = path[len(it.cfg.AppProjectPath)+1:]
+			hit     = func(name string) bool {
+				return name == relpath || strings.HasPrefix(name, relpath+"/")
+			}
+		)
+
+		for _, e := range it.assets.Errors() {
+			if hit(e.File) {
+				it.assets.SetErrors(e.File, nil)
 			}
 		}
 
-		return nil
+		for fpath, name := range pagelets {
+			if hit(fpath) {
+				it.assets.DelPagelet(name)
+				delete(pagelets, fpath)
+				hlog.Printf("info", "asset %s, name %s, removed", fpath, name)
+			}
+		}
+
+		for _, name := range it.assets.List("") {
+			if hit(name) && appTemplateFileRx.MatchString(name) {
+				it.assets.Del(name)
+				hlog.Printf("info", "asset %s, removed", name)
+			}
+		}
 	}
 
 	watcher, err := fsnotify.NewWatcher()
@@ -377,18 +566,45 @@ func (it *serviceImpl) appAssetsRefresh() error {
 		return err
 	}
 
-	if err = filepath.Walk(it.cfg.AppProjectPath, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
+	walk := func(root string) error {
+		return filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				hlog.Printf("info", "watch %s", path)
+				return watcher.Add(path)
+			}
+			return load(path)
+		})
+	}
+
+	pending = map[string]*lynkui.Pagelet{}
+	if err = walk(it.cfg.AppProjectPath); err != nil {
+		return err
+	}
+	relpaths := make([]string, 0, len(pending))
+	for relpath := range pending {
+		relpaths = append(relpaths, relpath)
+	}
+	sort.Strings(relpaths)
+	// an invalid pagelet leaves the pending set, so the pagelets which
+	// refer to it fail on the next pass
+	for n := -1; n != len(pending); {
+		n = len(pending)
+		for _, relpath := range relpaths {
+			if item, ok := pending[relpath]; ok && len(validator.validate(relpath, item)) > 0 {
+				store(it.cfg.AppProjectPath+"/"+relpath, relpath, item)
+				delete(pending, relpath)
+			}
 		}
-		if info.IsDir() {
-			hlog.Printf("info", "watch %s", path)
-			return watcher.Add(path)
+	}
+	for _, relpath := range relpaths {
+		if item, ok := pending[relpath]; ok {
+			store(it.cfg.AppProjectPath+"/"+relpath, relpath, item)
 		}
-		return load(path)
-	}); err != nil {
-		return err
 	}
+	pending = nil
 
 	go func() {
 		defer watcher.Close()
@@ -404,15 +620,73 @@ func (it *serviceImpl) appAssetsRefresh() error {
 
 				// hlog.Printf("info", "fsnotify event hit %v, file %v", event.Op, event.Name)
 
-				if !ok || (!appPageletFileRx.MatchString(event.Name) &&
-					!appTemplateFileRx.MatchString(event.Name)) {
+				if !ok {
+					continue
+				}
+
+				// a renamed file is reported as Rename of the old name
+				// and Create of the new one
+				if (event.Op&fsnotify.Remove) == fsnotify.Remove ||
+					(event.Op&fsnotify.Rename) == fsnotify.Rename {
+					hlog.Printf("info", "fsnotify event %v, file %v", event.Op, event.Name)
+					delete(updates, event.Name)
+					unload(event.Name)
+					continue
+				}
+
+				if (event.Op & fsnotify.Create) == fsnotify.Create {
+					if st, err := os.Stat(event.Name); err == nil && st.IsDir() {
+						if err := walk(event.Name); err != nil {
+							hlog.Printf("warn", "watch %s, err %s", event.Name, err.Error())
+						}
+						continue
+					}
+				}
+
+				// removing the access file takes effect on restart only
+				if event.Name == it.cfg.AppProjectPath+"/"+appAccessFile {
+					if (event.Op&fsnotify.Create) == fsnotify.Create ||
+						(event.Op&fsnotify.Write) == fsnotify.Write {
+
+						tn := time.Now().UnixNano() / 1e6
+						if (tn - updates[event.Name]) < 1e3 {
+							continue
+						}
+						updates[event.Name] = tn
+
+						time.Sleep(100e6)
+						hlog.Printf("info", "fsnotify event %v, file %v", event.Op, event.Name)
+
+						it.accessReload()
+					}
+					continue
+				}
+
+				if event.Name == it.cfg.AppProjectPath+"/lynkui_layout.json" {
+					if (event.Op&fsnotify.Create) == fsnotify.Create ||
+						(event.Op&fsnotify.Write) == fsnotify.Write {
+
+						tn := time.Now().UnixNano() / 1e6
+						if (tn - updates[event.Name]) < 1e3 {
+							continue
+						}
+						updates[event.Name] = tn
+
+						time.Sleep(100e6)
+						hlog.Printf("info", "fsnotify event %v, file %v", event.Op, event.Name)
+
+						it.layoutReload()
+					}
+					continue
+				}
+
+				if !appPageletFileRx.MatchString(event.Name) &&
+					!appTemplateFileRx.MatchString(event.Name) {
 					continue
 				}
 
 				if (event.Op&fsnotify.Create) == fsnotify.Create ||
-					(event.Op&fsnotify.Write) == fsnotify.Write ||
-					// (event.Op&fsnotify.Rename) == fsnotify.Rename ||
-					(event.Op&fsnotify.Remove) == fsnotify.Remove {
+					(event.Op&fsnotify.Write) == fsnotify.Write {
 
 					tn := time.Now().UnixNano() / 1e6
 					if (tn - updates[event.Name]) < 1e3 {
@@ -436,3 +710,44 @@ func (it *serviceImpl) appAssetsRefresh() error {
 
 	return nil
 }
+
+func (it *serviceImpl) layoutReload() {
+
+	const relpath = "lynkui_layout.json"
+
+	chg, err := it.layout.Reload()
+	if err != nil {
+		hlog.Printf("warn", "layout reload fail %s", err.Error())
+		it.assets.SetErrors(relpath, []*lynkui.ValidateError{{
+			File:    relpath,
+			Message: err.Error(),
+		}})
+		return
+	}
+	it.assets.SetErrors(relpath, nil)
+
+	if chg.Empty() {
+		return
+	}
+
+	hlog.Printf("info", "layout reload, tables added %v, updated %v, removed %v",
+		chg.TablesAdded, chg.TablesUpdated, chg.TablesRemoved)
+	hlog.Printf("info", "layout reload, instances added %v, updated %v, removed %v",
+		chg.InstancesAdded, chg.InstancesUpdated, chg.InstancesRemoved)
+	for _, msg := range chg.Errors {
+		hlog.Printf("warn", "layout reload, %s", msg)
+	}
+}
+
+func (it *serviceImpl) accessReload() {
+	if err := it.access.Load(it.cfg.AppProjectPath + "/" + appAccessFile); err != nil {
+		hlog.Printf("warn", "access reload fail %s", err.Error())
+		it.assets.SetErrors(appAccessFile, []*lynkui.ValidateError{{
+			File:    appAccessFile,
+			Message: err.Error(),
+		}})
+		return
+	}
+	it.assets.SetErrors(appAccessFile, nil)
+	hlog.Printf("info", "access reload, enabled %v", it.access.Enabled())
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hooto/httpsrv"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+// testService starts a project service with the files on a local port and
+// returns its base url.
+func testService(t *testing.T, files map[string]string) (*serviceImpl, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	lis.Close()
+
+	s := httpsrv.NewService()
+	s.Config.HttpAddr = "127.0.0.1"
+	s.Config.HttpPort = uint16(port)
+
+	// the hosts are registered process wide, each test has its own path
+	entry := fmt.Sprintf("/lynkui%d", port)
+
+	svc, err := NewService(s, &lynkui.ServiceConfig{
+		AppProjectPath: dir,
+		UrlEntryPath:   entry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(func() {
+		s.Stop()
+		svc.Close()
+	})
+
+	base := fmt.Sprintf("http://127.0.0.1:%d%s/api/v1", port, entry)
+	for i := 0; ; i++ {
+		if rsp, err := http.Get(base + "/pagelet/fetch"); err == nil {
+			rsp.Body.Close()
+			break
+		} else if i >= 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return svc.(*serviceImpl), base
+}
+
+func testGetJson(base, path string, v interface{}) error {
+	rsp, err := http.Get(base + path)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+// TestPageletConcurrentRequests runs the fetch and run requests of the same
+// pagelet with different query filters while the pagelet and the layout are
+// reloaded, each response must only reflect its own request. Run with -race.
+func TestPageletConcurrentRequests(t *testing.T) {
+
+	svc, base := testService(t, map[string]string{
+		"lynkui_data.json": `{"lynk_dict": [
+			{"id": "a1", "ns": "a", "name": "a1"},
+			{"id": "a2", "ns": "a", "name": "a2"},
+			{"id": "b1", "ns": "b", "name": "b1"}
+		]}`,
+		"pagelet/dict.json": `{
+			"kind": "Pagelet",
+			"datalet": {"table_name": "lynk_dict"}
+		}`,
+	})
+
+	pl := svc.assets.Pagelet("dict")
+	if pl == nil {
+		t.Fatal("pagelet dict not loaded")
+	}
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+		errs = make(chan error, 64)
+	)
+
+	// the reload of the pagelet and the data layout by the file watcher
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			item := proto.Clone(pl).(*lynkui.Pagelet)
+			item.DisplayName = fmt.Sprintf("dict %d", i)
+			svc.assets.SetPagelet(item.Name, item)
+			svc.layoutReload()
+		}
+	}()
+
+	for _, ns := range []string{"a", "b", "a", "b"} {
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+
+			filter := base64.StdEncoding.EncodeToString(
+				[]byte(`{"field":"ns","value":"` + ns + `"}`))
+
+			for i := 0; i < 20; i++ {
+
+				var rs struct {
+					Results []struct {
+						Rows []struct {
+							Fields map[string]interface{} `json:"fields"`
+						} `json:"rows"`
+					} `json:"results"`
+				}
+				if err := testGetJson(base, "/datalet/run?pagelet=dict&query_filter="+filter, &rs); err != nil {
+					errs <- err
+					return
+				}
+				if len(rs.Results) != 1 || len(rs.Results[0].Rows) == 0 {
+					errs <- fmt.Errorf("ns %s : no rows", ns)
+					return
+				}
+				for _, row := range rs.Results[0].Rows {
+					if row.Fields["ns"] != ns {
+						errs <- fmt.Errorf("ns %s : row of ns %v", ns, row.Fields["ns"])
+						return
+					}
+				}
+
+				var item lynkui.Pagelet
+				if err := testGetJson(base, "/pagelet/fetch?name=dict", &item); err != nil {
+					errs <- err
+					return
+				}
+				if item.Datalet == nil || item.Datalet.Filter != nil ||
+					item.Datalet.GetQuery().GetFilter() != nil {
+					errs <- fmt.Errorf("ns %s : fetch leaks a filter", ns)
+					return
+				}
+			}
+		}(ns)
+	}
+
+	wg.Wait()
+	close(done)
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if reg := svc.assets.Pagelet("dict"); reg.Datalet.Filter != nil || reg.Datalet.Query != nil {
+		t.Errorf("registered pagelet modified : %v", reg.Datalet)
+	}
+}
+
+// TestPageletInvalidReference checks that an invalid pagelet is not
+// registered, and neither are the pagelets which refer to it.
+func TestPageletInvalidReference(t *testing.T) {
+
+	svc, _ := testService(t, map[string]string{
+		"pagelet/index.json": `{"kind": "Pagelet", "next_pagelets": [{"name": "dict"}]}`,
+		"pagelet/dict.json":  `{"kind": "Pagelet", "datalet": {"table_name": "not_found"}}`,
+		"pagelet/other.json": `{"kind": "Pagelet"}`,
+	})
+
+	for name, want := range map[string]bool{"index": false, "dict": false, "other": true} {
+		if got := svc.assets.Pagelet(name) != nil; got != want {
+			t.Errorf("pagelet %s registered %v, want %v", name, got, want)
+		}
+	}
+
+	files := map[string]bool{}
+	for _, e := range svc.assets.Errors() {
+		files[e.File] = true
+	}
+	if !files["pagelet/index.json"] || !files["pagelet/dict.json"] {
+		t.Errorf("validate errors not reported : %v", files)
+	}
+}
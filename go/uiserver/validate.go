@@ -0,0 +1,214 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uiserver
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/bindata"
+)
+
+type pageletValidator struct {
+	tableExists    func(name string) bool
+	pageletExists  func(name string) bool
+	templateExists func(file string) bool
+}
+
+func (it *pageletValidator) validate(file string, pl *lynkui.Pagelet) []*lynkui.ValidateError {
+
+	var errs []*lynkui.ValidateError
+
+	errorf := func(field, format string, args ...interface{}) {
+		errs = append(errs, &lynkui.ValidateError{
+			File:    file,
+			Pagelet: pl.Name,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if pl.Datalet != nil {
+		if pl.Datalet.TableName == "" {
+			errorf("datalet.table_name", "table name not setup")
+		} else if !it.tableExists(pl.Datalet.TableName) {
+			errorf("datalet.table_name", "table (%s) not found in data layout", pl.Datalet.TableName)
+		}
+	}
+
+	for i, v := range pl.NextPagelets {
+		if v.Name == "" {
+			errorf(fmt.Sprintf("next_pagelets[%d].name", i), "pagelet name not setup")
+		} else if !it.pageletExists(v.Name) {
+			errorf(fmt.Sprintf("next_pagelets[%d].name", i), "pagelet (%s) not found", v.Name)
+		}
+	}
+
+	if pl.Event != nil {
+		switch pl.Event.Name {
+		case "onclick":
+		default:
+			errorf("event.name", "unknown event (%s)", pl.Event.Name)
+		}
+		if pl.Event.Pagelet == "" {
+			errorf("event.pagelet", "pagelet name not setup")
+		} else if !it.pageletExists(pl.Event.Pagelet) {
+			errorf("event.pagelet", "pagelet (%s) not found", pl.Event.Pagelet)
+		}
+	}
+
+	if pl.Template != nil && pl.Template.Layout != nil {
+		if err := pl.Template.Layout.Validate(); err != nil {
+			errorf("template.layout", "%s", err.Error())
+		}
+	}
+
+	if pl.Template != nil && pl.Template.Nav != nil {
+		if err := pl.Template.Nav.Validate(); err != nil {
+			errorf("template.nav", "%s", err.Error())
+		}
+	}
+
+	if pl.Template != nil && pl.Template.Html != nil &&
+		pl.Template.Html.Html == "" {
+		if pl.Template.Html.File == "" {
+			errorf("template.html.file", "template file not setup")
+		} else if !it.templateExists(pl.Template.Html.File) {
+			errorf("template.html.file", "template file (%s) not found", pl.Template.Html.File)
+		}
+	}
+
+	return errs
+}
+
+func coreTemplateExists(file string) bool {
+	if bindata.Assets == nil {
+		return false
+	}
+	_, err := bindata.Assets.ReadFile("/lynkui/tpl/" + file)
+	return err == nil
+}
+
+// ValidateProject checks all pagelets of the project, without starting a
+// service or writing any project file.
+func ValidateProject(projPath string) ([]*lynkui.ValidateError, error) {
+
+	projPath, err := filepath.Abs(projPath)
+	if err != nil {
+		return nil, err
+	}
+	projPath = filepath.Clean(projPath)
+
+	var (
+		layout    lynkui.DataLayout
+		tables    = map[string]bool{"lynk_dict": true, "lynk_audit": true}
+		templates = map[string]bool{}
+		pagelets  = map[string]*lynkui.Pagelet{}
+		files     = map[string]string{} // pagelet name -> relpath
+		errs      []*lynkui.ValidateError
+	)
+
+	if b, err := os.ReadFile(projPath + "/lynkui_layout.json"); err == nil {
+		if err = codec.Json.Decode(b, &layout); err != nil {
+			errs = append(errs, &lynkui.ValidateError{
+				File:    "lynkui_layout.json",
+				Message: err.Error(),
+			})
+		}
+		for _, vt := range layout.Tables {
+			tables[vt.Name] = true
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err = filepath.Walk(projPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || len(path) <= len(projPath) {
+			return nil
+		}
+		relpath := path[len(projPath)+1:]
+		switch {
+		case appPageletFileRx.MatchString(relpath):
+			mat := appPageletFileRx.FindStringSubmatch(relpath)
+			if len(mat) != 3 {
+				return nil
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if fpath, ok := files[mat[1]]; ok {
+				errs = append(errs, &lynkui.ValidateError{
+					File:    relpath,
+					Pagelet: mat[1],
+					Message: fmt.Sprintf("pagelet already defined in %s", fpath),
+				})
+				return nil
+			}
+			var item lynkui.Pagelet
+			if err = pageletDecode(mat[2], b, &item); err != nil {
+				errs = append(errs, &lynkui.ValidateError{
+					File:    relpath,
+					Pagelet: mat[1],
+					Message: err.Error(),
+				})
+				return nil
+			}
+			item.Name = mat[1]
+			pagelets[relpath] = &item
+			files[mat[1]] = relpath
+
+		case appTemplateFileRx.MatchString(relpath):
+			if mat := appTemplateFileRx.FindStringSubmatch(relpath); len(mat) == 3 {
+				templates[mat[1]+mat[2]] = true
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	vr := &pageletValidator{
+		tableExists: func(name string) bool {
+			return tables[name]
+		},
+		pageletExists: func(name string) bool {
+			_, ok := files[name]
+			return ok || name == auditPageletName
+		},
+		templateExists: func(file string) bool {
+			return templates[file] || coreTemplateExists(file)
+		},
+	}
+
+	for relpath, pl := range pagelets {
+		errs = append(errs, vr.validate(relpath, pl)...)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].File < errs[j].File
+	})
+
+	return errs, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package access
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const wildcard = "*"
+
+// Manager holds the access policy of one project. Without a policy, or for
+// requests without a user (authentication disabled), everything is granted.
+type Manager struct {
+	mu     sync.RWMutex
+	policy *lynkui.AccessPolicy
+}
+
+// TableGrant is what a user may do with a virtual table.
+type TableGrant struct {
+	Read     bool
+	Write    bool
+	Hidden   map[string]bool
+	ReadOnly map[string]bool
+}
+
+var grantAll = &TableGrant{
+	Read:     true,
+	Write:    true,
+	Hidden:   map[string]bool{},
+	ReadOnly: map[string]bool{},
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Load reads the policy file, a missing file disables the access control.
+// On errors the previous policy is kept.
+func (it *Manager) Load(file string) error {
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			it.mu.Lock()
+			it.policy = nil
+			it.mu.Unlock()
+			return nil
+		}
+		return err
+	}
+
+	var policy lynkui.AccessPolicy
+	if err := codec.Json.Decode(b, &policy); err != nil {
+		return err
+	}
+
+	if err := policyValidate(&policy); err != nil {
+		return err
+	}
+
+	it.mu.Lock()
+	it.policy = &policy
+	it.mu.Unlock()
+
+	return nil
+}
+
+func policyValidate(policy *lynkui.AccessPolicy) error {
+	names := map[string]bool{}
+	for i, role := range policy.Roles {
+		if role == nil || role.Name == "" {
+			return fmt.Errorf("roles[%d].name not setup", i)
+		}
+		if names[role.Name] {
+			return fmt.Errorf("role (%s) already defined", role.Name)
+		}
+		names[role.Name] = true
+		for j, t := range role.Tables {
+			if t == nil || t.Name == "" {
+				return fmt.Errorf("role (%s) tables[%d].name not setup", role.Name, j)
+			}
+			if t.Name != wildcard && !lynkapi.NameIdentifier.MatchString(t.Name) {
+				return fmt.Errorf("role (%s) invalid table name (%s)", role.Name, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (it *Manager) Enabled() bool {
+	if it == nil {
+		return false
+	}
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	return it.policy != nil
+}
+
+func (it *Manager) roles(user *lynkui.User) ([]*lynkui.AccessRole, bool) {
+	if it == nil {
+		return nil, false
+	}
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	if it.policy == nil || user == nil {
+		return nil, false
+	}
+	var roles []*lynkui.AccessRole
+	for _, role := range it.policy.Roles {
+		if slices.Contains(user.Roles, role.Name) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, true
+}
+
+// Admin reports whether the user may run the project maintenance actions.
+func (it *Manager) Admin(user *lynkui.User) bool {
+	roles, enabled := it.roles(user)
+	if !enabled {
+		return true
+	}
+	for _, role := range roles {
+		if role.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// Pagelet reports whether the user may open the pagelet.
+func (it *Manager) Pagelet(user *lynkui.User, name string) bool {
+	roles, enabled := it.roles(user)
+	if !enabled {
+		return true
+	}
+	for _, role := range roles {
+		if slices.Contains(role.Pagelets, wildcard) ||
+			slices.Contains(role.Pagelets, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Table returns the grant of the user on the table. A field is hidden or
+// read-only only if it is so in all roles granting the read or write.
+func (it *Manager) Table(user *lynkui.User, name string) *TableGrant {
+
+	roles, enabled := it.roles(user)
+	if !enabled {
+		return grantAll
+	}
+
+	var (
+		g = &TableGrant{
+			Hidden:   map[string]bool{},
+			ReadOnly: map[string]bool{},
+		}
+		hidden   map[string]int
+		readOnly map[string]int
+		nRead    int
+		nWrite   int
+	)
+
+	count := func(m map[string]int, names []string) map[string]int {
+		if m == nil {
+			m = map[string]int{}
+		}
+		for _, v := range names {
+			m[v]++
+		}
+		return m
+	}
+
+	for _, role := range roles {
+		for _, t := range role.Tables {
+			if t.Name != wildcard && t.Name != name {
+				continue
+			}
+			if t.Read || t.Write {
+				nRead++
+				hidden = count(hidden, slices.Compact(slices.Sorted(slices.Values(t.HiddenFields))))
+			}
+			if t.Write {
+				nWrite++
+				readOnly = count(readOnly, slices.Compact(slices.Sorted(slices.Values(
+					append(slices.Clone(t.ReadOnlyFields), t.HiddenFields...)))))
+			}
+		}
+	}
+
+	g.Read, g.Write = nRead > 0, nWrite > 0
+	for k, n := range hidden {
+		if n == nRead {
+			g.Hidden[k] = true
+		}
+	}
+	for k, n := range readOnly {
+		if n == nWrite {
+			g.ReadOnly[k] = true
+		}
+	}
+
+	return g
+}
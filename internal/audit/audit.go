@@ -0,0 +1,509 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/internal/data"
+)
+
+const (
+	InstanceName = "lynkui_audit"
+	TableName    = "lynk_audit"
+
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+const (
+	queryLimitDef = 20
+	queryLimitMax = 1000
+
+	// entries kept by a log without file or store
+	memEntriesMax = 10000
+
+	// entries a query reads from the store at most, the store filters,
+	// sorts and pages them as the file and memory logs do
+	storeEntriesMax = 100000
+)
+
+// Entry is one data change made through the console, Before and After are
+// the JSON encoded field values of the row.
+type Entry struct {
+	Id        string `json:"id"`
+	Created   int64  `json:"created"` // unix milliseconds
+	User      string `json:"user,omitempty"`
+	Pagelet   string `json:"pagelet,omitempty"`
+	TableName string `json:"table_name"`
+	Op        string `json:"op"`
+	Pk        string `json:"pk,omitempty"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+}
+
+// Log is the append-only audit trail of a project. The entries are written
+// to the lynk_audit table of the store if set, otherwise appended to the
+// JSONL file, or kept in memory if neither is set. It implements
+// lynkapi.DataService so that the trail is browsed as a virtual table, all
+// writes other than Record are rejected.
+//
+// The file is read once, into an index of the entries without their Before
+// and After values, which Record appends to. The values are read from the
+// file for the rows of a query result only.
+type Log struct {
+	mu     sync.Mutex
+	file   string
+	store  lynkapi.DataService
+	mem    []*Entry
+	index  []*logEntry
+	loaded bool
+	inst   *lynkapi.DataInstance
+}
+
+// logEntry is an entry of a query, the entries of the file index have the
+// offset and size of their line, and no Before and After values.
+type logEntry struct {
+	*Entry
+	off  int64 // -1 if the Entry is complete
+	size int
+}
+
+func NewLog(file string, store lynkapi.DataService) *Log {
+	return &Log{
+		file:  file,
+		store: store,
+		inst: &lynkapi.DataInstance{
+			Name: InstanceName,
+			Spec: &lynkapi.DataSpec{
+				Tables: []*lynkapi.TableSpec{tableSpec()},
+			},
+		},
+	}
+}
+
+func tableSpec() *lynkapi.TableSpec {
+	return &lynkapi.TableSpec{
+		Name: TableName,
+		Fields: []*lynkapi.FieldSpec{
+			{Name: "Id", TagName: "id", Type: lynkapi.FieldSpec_String, Attrs: []string{"primary_key"}},
+			{Name: "Created", TagName: "created", Type: lynkapi.FieldSpec_Int},
+			{Name: "User", TagName: "user", Type: lynkapi.FieldSpec_String},
+			{Name: "Pagelet", TagName: "pagelet", Type: lynkapi.FieldSpec_String},
+			{Name: "TableName", TagName: "table_name", Type: lynkapi.FieldSpec_String},
+			{Name: "Op", TagName: "op", Type: lynkapi.FieldSpec_String,
+				Enums: []string{OpCreate, OpUpdate, OpDelete}},
+			{Name: "Pk", TagName: "pk", Type: lynkapi.FieldSpec_String},
+			{Name: "Before", TagName: "before", Type: lynkapi.FieldSpec_StringText},
+			{Name: "After", TagName: "after", Type: lynkapi.FieldSpec_StringText},
+		},
+	}
+}
+
+// Record appends the entry to the trail, the id and time are set if empty.
+func (it *Log) Record(e *Entry) error {
+
+	if it == nil {
+		return nil
+	}
+
+	if e.Created == 0 {
+		e.Created = time.Now().UnixMilli()
+	}
+	if e.Id == "" {
+		// time ordered ids, so that the store keeps the entries apart
+		e.Id = strconv.FormatInt(e.Created, 16) + lynkapi.RandHexString(8)
+	}
+
+	if it.store != nil {
+		req := &lynkapi.DataInsert{
+			TableName: TableName,
+		}
+		for k, v := range entryFields(e) {
+			req.Fields = append(req.Fields, k)
+			req.Values = append(req.Values, v)
+		}
+		rs, err := it.store.Upsert(req)
+		if err == nil && rs.Status == nil {
+			err = fmt.Errorf("status not found")
+		} else if err == nil && !rs.Status.OK() {
+			err = rs.Status.Err()
+		}
+		return err
+	}
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.file == "" {
+		if len(it.mem) >= memEntriesMax {
+			it.mem = append(it.mem[:0], it.mem[len(it.mem)-memEntriesMax+1:]...)
+		}
+		it.mem = append(it.mem, e)
+		return nil
+	}
+
+	if err := it.load(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	fp, err := os.OpenFile(it.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	st, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+
+	b = append(b, '\n')
+	if _, err = fp.Write(b); err != nil {
+		return err
+	}
+
+	it.index = append(it.index, entryHead(e, st.Size(), len(b)))
+	return nil
+}
+
+// load reads the index of the file, once.
+func (it *Log) load() error {
+
+	if it.loaded {
+		return nil
+	}
+
+	fp, err := os.Open(it.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			it.loaded = true
+			return nil
+		}
+		return err
+	}
+	defer fp.Close()
+
+	var (
+		r   = bufio.NewReader(fp)
+		off int64
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e Entry
+			// a line cut by a crash is skipped, the following entries are
+			// still valid
+			if json.Unmarshal(line, &e) == nil {
+				it.index = append(it.index, entryHead(&e, off, len(line)))
+			}
+			off += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			it.index = nil
+			return err
+		}
+	}
+
+	it.loaded = true
+	return nil
+}
+
+func entryHead(e *Entry, off int64, size int) *logEntry {
+	head := *e
+	head.Before, head.After = "", ""
+	return &logEntry{
+		Entry: &head,
+		off:   off,
+		size:  size,
+	}
+}
+
+func entryFields(e *Entry) map[string]*structpb.Value {
+	return map[string]*structpb.Value{
+		"id":         structpb.NewStringValue(e.Id),
+		"created":    structpb.NewNumberValue(float64(e.Created)),
+		"user":       structpb.NewStringValue(e.User),
+		"pagelet":    structpb.NewStringValue(e.Pagelet),
+		"table_name": structpb.NewStringValue(e.TableName),
+		"op":         structpb.NewStringValue(e.Op),
+		"pk":         structpb.NewStringValue(e.Pk),
+		"before":     structpb.NewStringValue(e.Before),
+		"after":      structpb.NewStringValue(e.After),
+	}
+}
+
+func (it *Log) Instance() *lynkapi.DataInstance {
+	return it.inst
+}
+
+func (it *Log) Query(q *lynkapi.DataQuery) (*lynkapi.DataResult, error) {
+
+	if q.TableName != TableName {
+		return nil, fmt.Errorf("table (%s) not found", q.TableName)
+	}
+
+	// the file entries are read with their values if the query needs them
+	body := entryBodyFilter(q.Filter) || (q.Sort != nil && entryBodyField(q.Sort.Field))
+
+	entries, err := it.entries(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Filter != nil {
+		matched := entries[:0]
+		for _, e := range entries {
+			if data.FilterMatch(q.Filter, entryFields(e.Entry)) {
+				matched = append(matched, e)
+			}
+		}
+		entries = matched
+	}
+
+	sortEntries(entries, q.Sort)
+
+	limit := int(q.Limit)
+	if limit <= 0 {
+		limit = queryLimitDef
+	} else if limit > queryLimitMax {
+		limit = queryLimitMax
+	}
+
+	offset := 0
+	if q.Offset != "" {
+		if offset, err = strconv.Atoi(q.Offset); err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid offset")
+		}
+	}
+
+	var page []*logEntry
+	if offset < len(entries) {
+		page = entries[offset:min(offset+limit, len(entries))]
+	}
+	if err := it.entryBodies(page); err != nil {
+		return nil, err
+	}
+
+	rs := &lynkapi.DataResult{
+		Spec: tableSpec(),
+	}
+	for _, e := range page {
+		rs.Rows = append(rs.Rows, &lynkapi.DataRow{
+			Id:     e.Id,
+			Fields: entryFields(e.Entry),
+		})
+	}
+	if n := offset + len(rs.Rows); n < len(entries) {
+		rs.NextOffset = strconv.Itoa(n)
+	}
+
+	if len(rs.Rows) == 0 {
+		rs.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_NotFound, "")
+	} else {
+		rs.Status = lynkapi.NewServiceStatusOK()
+	}
+	return rs, nil
+}
+
+// entries returns all entries of the log, the file entries without their
+// values unless body is set.
+func (it *Log) entries(body bool) ([]*logEntry, error) {
+
+	if it.store != nil {
+		return it.storeEntries()
+	}
+
+	it.mu.Lock()
+
+	if it.file == "" {
+		entries := make([]*logEntry, len(it.mem))
+		for i, e := range it.mem {
+			entries[i] = &logEntry{Entry: e, off: -1}
+		}
+		it.mu.Unlock()
+		return entries, nil
+	}
+
+	if err := it.load(); err != nil {
+		it.mu.Unlock()
+		return nil, err
+	}
+	entries := slices.Clone(it.index)
+	it.mu.Unlock()
+
+	if body {
+		if err := it.entryBodies(entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// entryBodies reads the values of the file entries from their lines, the
+// entries are replaced and not changed, the index shares them.
+func (it *Log) entryBodies(entries []*logEntry) error {
+
+	var fp *os.File
+	for i, e := range entries {
+		if e.off < 0 {
+			continue
+		}
+		if fp == nil {
+			var err error
+			if fp, err = os.Open(it.file); err != nil {
+				return err
+			}
+			defer fp.Close()
+		}
+		b := make([]byte, e.size)
+		if _, err := fp.ReadAt(b, e.off); err != nil {
+			return err
+		}
+		var full Entry
+		if err := json.Unmarshal(b, &full); err != nil {
+			return err
+		}
+		entries[i] = &logEntry{Entry: &full, off: -1}
+	}
+	return nil
+}
+
+// storeEntries reads the entries of the store, which do not sort or page
+// them by the query.
+func (it *Log) storeEntries() ([]*logEntry, error) {
+
+	q := &lynkapi.DataQuery{
+		TableName: TableName,
+		Limit:     storeEntriesMax,
+	}
+	if inst := it.store.Instance(); inst != nil {
+		q.InstanceName = inst.Name
+	}
+
+	rs, err := it.store.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	if rs.Status == nil {
+		return nil, fmt.Errorf("status not found")
+	}
+	if rs.Status.Code == lynkapi.StatusCode_NotFound {
+		return nil, nil
+	}
+	if !rs.Status.OK() {
+		return nil, rs.Status.Err()
+	}
+
+	entries := make([]*logEntry, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		entries = append(entries, &logEntry{Entry: entryOf(row.Fields), off: -1})
+	}
+	return entries, nil
+}
+
+func entryOf(fields map[string]*structpb.Value) *Entry {
+	return &Entry{
+		Id:        fields["id"].GetStringValue(),
+		Created:   int64(fields["created"].GetNumberValue()),
+		User:      fields["user"].GetStringValue(),
+		Pagelet:   fields["pagelet"].GetStringValue(),
+		TableName: fields["table_name"].GetStringValue(),
+		Op:        fields["op"].GetStringValue(),
+		Pk:        fields["pk"].GetStringValue(),
+		Before:    fields["before"].GetStringValue(),
+		After:     fields["after"].GetStringValue(),
+	}
+}
+
+// entryBodyField reports whether the field is one of the values which the
+// file index does not keep.
+func entryBodyField(name string) bool {
+	return name == "before" || name == "after"
+}
+
+func entryBodyFilter(fr *lynkapi.DataQuery_Filter) bool {
+	if fr == nil {
+		return false
+	}
+	if entryBodyField(fr.Field) {
+		return true
+	}
+	for _, v := range fr.Inner {
+		if entryBodyFilter(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEntries orders the entries by the sort field, the newest first by
+// default.
+func sortEntries(entries []*logEntry, sf *lynkapi.DataQuery_SortFilter) {
+
+	field, desc := "created", true
+	if sf != nil && sf.Field != "" {
+		field, desc = sf.Field, sf.Type == "desc"
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entryFields(entries[i].Entry)[field], entryFields(entries[j].Entry)[field]
+		c := 0
+		if _, ok := a.GetKind().(*structpb.Value_NumberValue); ok {
+			c = cmp.Compare(a.GetNumberValue(), b.GetNumberValue())
+		} else {
+			c = cmp.Compare(a.GetStringValue(), b.GetStringValue())
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func (it *Log) Upsert(q *lynkapi.DataInsert) (*lynkapi.DataResult, error) {
+	return nil, lynkapi.NewBadRequestError("audit log is append only")
+}
+
+func (it *Log) Igsert(q *lynkapi.DataInsert) (*lynkapi.DataResult, error) {
+	return nil, lynkapi.NewBadRequestError("audit log is append only")
+}
+
+func (it *Log) Delete(q *lynkapi.DataDelete) (*lynkapi.DataResult, error) {
+	return nil, lynkapi.NewBadRequestError("audit log is append only")
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+const (
+	requestTimeoutDef = 30 * time.Second
+	requestTimeoutMax = 10 * time.Minute
+
+	// a replaced client is closed once the requests started on it are done
+	clientRetireDelay = requestTimeoutMax + time.Minute
+)
+
+type tableRef struct {
+	name     string
+	instance string
+	table    string
+	timeout  time.Duration
+	service  lynkapi.DataService
+	scan     bool
+	client   lynkapi.Client
+	filter   *lynkapi.DataQuery_Filter
+}
+
+// tableRef resolves the virtual table, the request itself runs without
+// holding the layout lock.
+func (it *LayoutManager) tableRef(name string) (*tableRef, error) {
+
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+
+	vt, ok := it.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table (%s) not found", name)
+	}
+	if vt.RefInstance == "" || vt.RefTable == "" {
+		return nil, fmt.Errorf("ref-table not found")
+	}
+
+	return &tableRef{
+		name:     name,
+		instance: vt.RefInstance,
+		table:    vt.RefTable,
+		timeout:  it.requestTimeout(vt.RefInstance),
+		service:  it.services[vt.RefInstance],
+		scan:     queryScanDriver(it.instances[vt.RefInstance]),
+		client:   it.clients[vt.RefInstance],
+		filter:   vt.RowFilter,
+	}, nil
+}
+
+func (it *LayoutManager) requestTimeout(name string) time.Duration {
+	ms := it.layout.TimeoutMs
+	for _, v := range it.layout.InstanceOptions {
+		if v.Name == name && v.TimeoutMs > 0 {
+			ms = v.TimeoutMs
+			break
+		}
+	}
+	if ms <= 0 {
+		return requestTimeoutDef
+	}
+	if d := time.Duration(ms) * time.Millisecond; d < requestTimeoutMax {
+		return d
+	}
+	return requestTimeoutMax
+}
+
+// clientCall runs the request on the remote client within the deadline of
+// the instance and the context. The call itself is not interruptible, on
+// cancel it is left to finish in the background.
+func (it *LayoutManager) clientCall(ctx context.Context, ref *tableRef,
+	fn func() *lynkapi.DataResult) *lynkapi.DataResult {
+
+	if err := ctx.Err(); err != nil {
+		return contextResult(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ref.timeout)
+	defer cancel()
+
+	ch := make(chan *lynkapi.DataResult, 1)
+	go func() {
+		ch <- fn()
+	}()
+
+	select {
+	case rs := <-ch:
+		it.healthReport(ref.instance, rs)
+		if rs.Status == nil {
+			rs.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_Timeout, "status not found")
+		}
+		return rs
+
+	case <-ctx.Done():
+		rs := contextResult(ctx.Err())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			it.healthReport(ref.instance, rs)
+		}
+		return rs
+	}
+}
+
+func contextResult(err error) *lynkapi.DataResult {
+	msg := "request canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		msg = "request timeout"
+	}
+	return &lynkapi.DataResult{
+		Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_Timeout, msg),
+	}
+}
+
+// clientRetire closes the client after the retire delay.
+func clientRetire(c lynkapi.Client) {
+	if cc, ok := c.(clientCloser); ok {
+		time.AfterFunc(clientRetireDelay, func() {
+			cc.Close()
+		})
+	}
+}
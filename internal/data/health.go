@@ -0,0 +1,307 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const (
+	healthTickInterval  = time.Second
+	healthProbeInterval = 10 * time.Second
+	healthRetryMin      = time.Second
+	healthRetryMax      = time.Minute
+)
+
+func healthRetryDelay(failures int) time.Duration {
+	d := healthRetryMin
+	for i := 1; i < failures && d < healthRetryMax; i++ {
+		d *= 2
+	}
+	if d > healthRetryMax {
+		d = healthRetryMax
+	}
+	return d
+}
+
+// healthSet records the result of a connect, probe or request of the
+// remote instance.
+func (it *LayoutManager) healthSet(name string, err error) {
+	it.healthMu.Lock()
+	defer it.healthMu.Unlock()
+
+	h, ok := it.health[name]
+	if !ok {
+		h = &lynkui.InstanceHealth{
+			Name: name,
+		}
+		it.health[name] = h
+	}
+
+	tn := time.Now()
+	h.Checked = tn.UnixMilli()
+
+	if err == nil {
+		h.Healthy, h.Failures, h.LastError, h.NextRetry = true, 0, "", 0
+		return
+	}
+
+	h.Healthy = false
+	h.Failures += 1
+	h.LastError = err.Error()
+	h.NextRetry = tn.Add(healthRetryDelay(h.Failures)).UnixMilli()
+}
+
+func (it *LayoutManager) healthDel(name string) {
+	it.healthMu.Lock()
+	defer it.healthMu.Unlock()
+	delete(it.health, name)
+}
+
+func (it *LayoutManager) healthGet(name string) *lynkui.InstanceHealth {
+	it.healthMu.Lock()
+	defer it.healthMu.Unlock()
+	if h, ok := it.health[name]; ok {
+		h2 := *h
+		return &h2
+	}
+	return nil
+}
+
+// healthReport checks the result of a request to the remote instance.
+func (it *LayoutManager) healthReport(name string, rs *lynkapi.DataResult) {
+	if rs.Status == nil {
+		it.healthSet(name, fmt.Errorf("status not found"))
+		return
+	}
+	switch rs.Status.Code {
+	case lynkapi.StatusCode_Timeout, lynkapi.StatusCode_ServiceUnavailable:
+		it.healthSet(name, fmt.Errorf("%s", rs.Status.Message))
+
+	case lynkapi.StatusCode_InternalServerError:
+		// transport errors are reported as internal errors by the client,
+		// probe on the next tick instead of marking the instance down
+		it.healthMu.Lock()
+		if h, ok := it.health[name]; ok && h.Healthy {
+			h.Checked = 0
+		}
+		it.healthMu.Unlock()
+	}
+}
+
+// healthUnavailable returns the result of a request to an instance which is
+// known to be down, nil if the request should be tried.
+func (it *LayoutManager) healthUnavailable(name string) *lynkapi.DataResult {
+	h := it.healthGet(name)
+	if h == nil || h.Healthy {
+		return nil
+	}
+	return &lynkapi.DataResult{
+		Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_ServiceUnavailable,
+			fmt.Sprintf("instance (%s) unavailable : %s", name, h.LastError)),
+	}
+}
+
+// Health returns the state of all remote instances, ordered by name.
+func (it *LayoutManager) Health() []*lynkui.InstanceHealth {
+	it.healthMu.Lock()
+	defer it.healthMu.Unlock()
+	items := []*lynkui.InstanceHealth{}
+	for _, h := range it.health {
+		h2 := *h
+		items = append(items, &h2)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// healthLoop probes the remote instances until the manager is closed.
+func (it *LayoutManager) healthLoop() {
+	tr := time.NewTicker(healthTickInterval)
+	defer tr.Stop()
+	for {
+		select {
+		case <-it.healthStop:
+			return
+		case <-tr.C:
+			it.healthCheck()
+		}
+	}
+}
+
+func (it *LayoutManager) healthStopped() bool {
+	select {
+	case <-it.healthStop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the health checks and closes the clients of the remote
+// instances. The manager must not be used after.
+func (it *LayoutManager) Close() error {
+	it.closeOnce.Do(func() {
+		close(it.healthStop)
+
+		it.mu.Lock()
+		defer it.mu.Unlock()
+		for name, c := range it.clients {
+			if cc, ok := c.(clientCloser); ok {
+				cc.Close()
+			}
+			delete(it.clients, name)
+		}
+	})
+	return nil
+}
+
+func (it *LayoutManager) healthCheck() {
+
+	type target struct {
+		inst    *lynkapi.DataInstance
+		client  lynkapi.Client
+		timeout time.Duration
+	}
+
+	var (
+		tn      = time.Now().UnixMilli()
+		targets []*target
+	)
+
+	it.mu.RLock()
+	for name, inst := range it.instances {
+		if inst.Connect == nil {
+			continue
+		}
+		if _, ok := it.services[name]; ok {
+			continue
+		}
+		h := it.healthGet(name)
+		if h != nil {
+			if h.Healthy && tn-h.Checked < healthProbeInterval.Milliseconds() {
+				continue
+			}
+			if !h.Healthy && tn < h.NextRetry {
+				continue
+			}
+		}
+		targets = append(targets, &target{
+			inst:    inst,
+			client:  it.clients[name],
+			timeout: it.requestTimeout(name),
+		})
+	}
+	it.mu.RUnlock()
+
+	for _, t := range targets {
+
+		if it.healthStopped() {
+			return
+		}
+
+		if t.client != nil {
+			err := it.healthProbe(t.client, t.timeout)
+			it.healthSet(t.inst.Name, err)
+			if err == nil {
+				continue
+			}
+			// drop the client, it is reconnected once the retry delay passed
+			it.mu.Lock()
+			if it.clients[t.inst.Name] == t.client {
+				delete(it.clients, t.inst.Name)
+			}
+			it.mu.Unlock()
+			clientRetire(t.client)
+			continue
+		}
+
+		inst := proto.Clone(t.inst).(*lynkapi.DataInstance)
+		c, err := instanceConnect(inst)
+		if err == nil {
+			err = it.healthProbe(c, t.timeout)
+		}
+		it.healthSet(t.inst.Name, err)
+		if err != nil {
+			if c != nil {
+				if cc, ok := c.(clientCloser); ok {
+					cc.Close()
+				}
+			}
+			continue
+		}
+
+		it.mu.Lock()
+		if cur, ok := it.instances[t.inst.Name]; ok && cur == t.inst &&
+			it.clients[cur.Name] == nil && !it.healthStopped() {
+			it.clients[cur.Name] = c
+			if cur.Spec == nil || len(cur.Spec.Tables) == 0 {
+				cur.Spec = inst.Spec
+			}
+			c = nil
+		}
+		it.mu.Unlock()
+
+		// the instance was changed or removed, or the manager closed in the
+		// meantime
+		if c != nil {
+			if cc, ok := c.(clientCloser); ok {
+				cc.Close()
+			}
+		}
+	}
+}
+
+// healthProbe requests the project of the instance within the timeout, or
+// until the manager is closed. The call itself is not interruptible, it is
+// left to finish in the background.
+func (it *LayoutManager) healthProbe(c lynkapi.Client, timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ch := make(chan *lynkapi.DataProjectResponse, 1)
+	go func() {
+		ch <- c.DataProject(&lynkapi.DataProjectRequest{})
+	}()
+
+	var rs *lynkapi.DataProjectResponse
+	select {
+	case rs = <-ch:
+	case <-ctx.Done():
+		return fmt.Errorf("probe fail : request timeout")
+	case <-it.healthStop:
+		return fmt.Errorf("probe fail : manager closed")
+	}
+
+	if rs == nil || rs.Status == nil {
+		return fmt.Errorf("probe fail : status not found")
+	}
+	if !rs.Status.OK() {
+		return fmt.Errorf("probe fail : %s", rs.Status.Message)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthProbeTimeout(t *testing.T) {
+
+	var (
+		lm = NewLayoutManager()
+		c  = &testClient{name: "remote", block: make(chan struct{})}
+	)
+	defer close(c.block)
+	defer lm.Close()
+
+	tn := time.Now()
+	if err := lm.healthProbe(c, 50*time.Millisecond); err == nil {
+		t.Fatal("probe of a hanging instance, want an error")
+	}
+	if d := time.Since(tn); d > time.Second {
+		t.Fatalf("probe returned after %v", d)
+	}
+}
+
+func TestHealthLoopClose(t *testing.T) {
+
+	var (
+		lm    = NewLayoutManager()
+		c     = &testClient{name: "remote", block: make(chan struct{})}
+		probe = make(chan error, 1)
+		done  = make(chan struct{})
+	)
+	defer close(c.block)
+
+	go func() {
+		probe <- lm.healthProbe(c, time.Minute)
+	}()
+	go func() {
+		lm.healthLoop()
+		close(done)
+	}()
+
+	for c.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	lm.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("health loop still running after close")
+	}
+	select {
+	case err := <-probe:
+		if err == nil {
+			t.Fatal("probe interrupted by the close, want an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("probe still running after close")
+	}
+
+	// a second close is a no-op
+	lm.Close()
+}
@@ -15,16 +15,23 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"sync"
 
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
 	"github.com/lynkdb/lynkapi/go/codec"
 	"github.com/lynkdb/lynkapi/go/lynkapi"
 	"github.com/lynkdb/lynkui/go/lynkui"
 )
 
+// the rows a delete reads per page, before deleting them by primary keys
+const deletePageSize = 1000
+
 type LayoutManager struct {
 	mu     sync.RWMutex
 	layout lynkui.DataLayout
@@ -38,6 +45,17 @@ type LayoutManager struct {
 
 	file    string
 	flusher func() error
+
+	reloadMu sync.Mutex
+	changes  []*lynkui.LayoutChange
+
+	healthMu   sync.Mutex
+	health     map[string]*lynkui.InstanceHealth
+	healthOnce sync.Once
+	healthStop chan struct{}
+	closeOnce  sync.Once
+
+	specs specCache
 }
 
 type TableActive struct {
@@ -49,17 +67,26 @@ type DataService interface {
 	Upsert(req *lynkapi.DataInsert) (*lynkapi.DataResult, error)
 }
 
-var Layout = &LayoutManager{
-	tables:    map[string]*lynkui.DataLayout_VirtualTable{},
-	instances: map[string]*lynkapi.DataInstance{},
-	services:  map[string]lynkapi.DataService{},
-	clients:   map[string]lynkapi.Client{},
+func NewLayoutManager() *LayoutManager {
+	return &LayoutManager{
+		tables:     map[string]*lynkui.DataLayout_VirtualTable{},
+		instances:  map[string]*lynkapi.DataInstance{},
+		services:   map[string]lynkapi.DataService{},
+		clients:    map[string]lynkapi.Client{},
+		health:     map[string]*lynkui.InstanceHealth{},
+		healthStop: make(chan struct{}),
+		specs: specCache{
+			items: map[string]*specEntry{},
+		},
+	}
 }
 
-func Init(file string) error {
+// Init loads the data layout from file, the file is rewritten on changes
+// unless readonly is set.
+func (it *LayoutManager) Init(file string, readonly bool) error {
 
-	Layout.mu.Lock()
-	defer Layout.mu.Unlock()
+	it.mu.Lock()
+	defer it.mu.Unlock()
 
 	b, err := ioutil.ReadFile(file)
 	if err != nil && !os.IsNotExist(err) {
@@ -67,24 +94,60 @@ func Init(file string) error {
 	}
 
 	if err == nil {
-		if err = codec.Json.Decode(b, &Layout.layout); err != nil {
+		if err = codec.Json.Decode(b, &it.layout); err != nil {
 			return err
 		}
-		for _, vt := range Layout.layout.Tables {
-			Layout.tables[vt.Name] = vt
+	}
+
+	it.file = file
+
+	layoutSetup(&it.layout)
+
+	it.tables = layoutTables(&it.layout)
+
+	for _, inst := range it.layout.Instances {
+		it.instances[inst.Name] = inst
+		if inst.Connect != nil {
+			it.healthSet(inst.Name, it.clientConnect(inst))
 		}
 	}
 
-	Layout.file = file
+	it.healthOnce.Do(func() {
+		go it.healthLoop()
+	})
+
+	if readonly {
+		return nil
+	}
+
+	it.flusher = func() error {
+		b, _ := codec.Json.Encode(&it.layout, &codec.JsonOptions{
+			Width: 120,
+		})
+		return ioutil.WriteFile(it.file, b, 0640)
+	}
 
-	idx := Layout.table("lynk_dict")
-	if idx == nil {
-		Layout.layout.Tables = append(Layout.layout.Tables, &lynkui.DataLayout_VirtualTable{
+	return it.flusher()
+}
+
+// layoutSetup adds the built-in lynk_dict table and its default refs, the
+// table is saved with the layout.
+func layoutSetup(layout *lynkui.DataLayout) {
+
+	hit := false
+	for _, vt := range layout.Tables {
+		if vt.Name == "lynk_dict" {
+			hit = true
+			break
+		}
+	}
+	if !hit {
+		layout.Tables = append(layout.Tables, &lynkui.DataLayout_VirtualTable{
 			Name: "lynk_dict",
 		})
 	}
 
-	for _, vt := range Layout.layout.Tables {
+	for _, vt := range layout.Tables {
 		switch vt.Name {
 		case "lynk_dict":
 			if vt.RefInstance == "" {
@@ -94,22 +157,28 @@ func Init(file string) error {
 				vt.RefTable = "lynk_dict"
 			}
 		}
-		Layout.tables[vt.Name] = vt
 	}
+}
 
-	Layout.flusher = func() error {
-		b, _ := codec.Json.Encode(Layout.layout, &codec.JsonOptions{
-			Width: 120,
-		})
-		return ioutil.WriteFile(Layout.file, b, 0640)
+// layoutTables returns the tables of the layout by name. The lynk_audit
+// table is added here unless the layout sets it, it is never written to the
+// layout file.
+func layoutTables(layout *lynkui.DataLayout) map[string]*lynkui.DataLayout_VirtualTable {
+
+	tables := map[string]*lynkui.DataLayout_VirtualTable{}
+	for _, vt := range layout.Tables {
+		tables[vt.Name] = vt
 	}
 
-	for _, inst := range Layout.layout.Instances {
-		Layout.instances[inst.Name] = inst
-		Layout.clientConnect(inst)
+	if _, ok := tables["lynk_audit"]; !ok {
+		tables["lynk_audit"] = &lynkui.DataLayout_VirtualTable{
+			Name:        "lynk_audit",
+			RefInstance: "lynkui_audit",
+			RefTable:    "lynk_audit",
+		}
 	}
 
-	return Layout.flusher()
+	return tables
 }
 
 func (it *LayoutManager) clientConnect(inst *lynkapi.DataInstance) error {
@@ -198,123 +267,377 @@ func (it *LayoutManager) RegisterService(ds lynkapi.DataService) error {
 	return nil
 }
 
+func (it *LayoutManager) HasTable(name string) bool {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	_, ok := it.tables[name]
+	return ok
+}
+
+// TableSpec returns the spec of the virtual table. The specs of remote
+// instances come from the spec cache, with the layout file as fallback.
 func (it *LayoutManager) TableSpec(name string) *lynkapi.TableSpec {
 
 	it.mu.RLock()
-	defer it.mu.RUnlock()
 
 	vt, ok := it.tables[name]
-	if !ok {
-		return nil
-	}
-	if vt.RefInstance == "" || vt.RefTable == "" {
+	if !ok || vt.RefInstance == "" || vt.RefTable == "" {
+		it.mu.RUnlock()
 		return nil
 	}
 
 	inst, ok := it.instances[vt.RefInstance]
 	if !ok {
+		it.mu.RUnlock()
 		return nil
 	}
 
-	if inst.Spec == nil || len(inst.Spec.Tables) == 0 {
-		c, ok := it.clients[vt.RefInstance]
-		if !ok {
-			return nil
-		}
-		rs := c.DataProject(&lynkapi.DataProjectRequest{})
-		if rs.Status.OK() {
-			for _, v := range rs.Instances {
-				if v.Name != vt.RefInstance {
-					continue
-				}
-				if v.Spec != nil {
-					inst.Spec = v.Spec
-				}
-				break
+	var (
+		spec    = inst.TableSpec(vt.RefTable)
+		client  = it.clients[vt.RefInstance]
+		timeout = it.requestTimeout(vt.RefInstance)
+	)
+	if _, ok := it.services[vt.RefInstance]; ok {
+		client = nil
+	}
+
+	it.mu.RUnlock()
+
+	if client == nil {
+		return spec
+	}
+
+	if ds, err := it.remoteSpec(vt.RefInstance, client, timeout); err == nil && ds != nil {
+		for _, t := range ds.Tables {
+			if t.Name == vt.RefTable {
+				return t
 			}
 		}
+		return nil
 	}
 
-	return inst.TableSpec(vt.RefTable)
+	return spec
 }
 
 func (it *LayoutManager) Query(req *lynkapi.DataQuery) (*lynkapi.DataResult, error) {
+	return it.QueryContext(context.Background(), req)
+}
 
-	it.mu.RLock()
-	defer it.mu.RUnlock()
+func (it *LayoutManager) Upsert(req *lynkapi.DataInsert) (*lynkapi.DataResult, error) {
+	return it.UpsertContext(context.Background(), req)
+}
 
-	vt, ok := it.tables[req.TableName]
-	if !ok {
-		return nil, fmt.Errorf("table (%s) not found", req.TableName)
+func (it *LayoutManager) Delete(req *lynkapi.DataDelete) (*lynkapi.DataResult, error) {
+	return it.DeleteContext(context.Background(), req)
+}
+
+func (it *LayoutManager) QueryContext(ctx context.Context, req *lynkapi.DataQuery) (*lynkapi.DataResult, error) {
+	rs, _, err := it.QueryPageContext(ctx, req)
+	return rs, err
+}
+
+// QueryPageContext runs the query as QueryContext does, total is the number
+// of the matching rows or -1 if the instance does not count them.
+func (it *LayoutManager) QueryPageContext(ctx context.Context, req *lynkapi.DataQuery) (*lynkapi.DataResult, int64, error) {
+
+	// the request of the caller is kept as is, e.g. for the next page
+	req = proto.Clone(req).(*lynkapi.DataQuery)
+
+	ref, err := it.tableRef(req.TableName)
+	if err != nil {
+		return nil, -1, err
+	}
+	if fr, err := rowScope(ctx, ref); err != nil {
+		return nil, -1, err
+	} else if fr != nil {
+		req.Filter = filterAnd(req.Filter, fr)
 	}
-	if vt.RefInstance == "" || vt.RefTable == "" {
-		return nil, fmt.Errorf("ref-table not found")
+
+	return it.queryPage(ctx, ref, req)
+}
+
+func (it *LayoutManager) queryContext(ctx context.Context, req *lynkapi.DataQuery) (*lynkapi.DataResult, error) {
+	ref, err := it.tableRef(req.TableName)
+	if err != nil {
+		return nil, err
 	}
-	req.InstanceName = vt.RefInstance
-	req.TableName = vt.RefTable
+	rs, _, err := it.queryPage(ctx, ref, req)
+	return rs, err
+}
+
+func (it *LayoutManager) queryPage(ctx context.Context, ref *tableRef, req *lynkapi.DataQuery) (*lynkapi.DataResult, int64, error) {
+
+	req.InstanceName = ref.instance
+	req.TableName = ref.table
+
+	if ref.service != nil {
+
+		if err := ctx.Err(); err != nil {
+			return nil, -1, err
+		}
 
-	srv, ok := it.services[vt.RefInstance]
-	if ok {
+		if ref.scan {
+			return it.queryScan(ctx, ref, req)
+		}
 
-		rs, err := srv.Query(req)
+		rs, err := ref.service.Query(req)
 		if err != nil {
-			return nil, err
+			return nil, -1, err
+		}
+		if rs.Status == nil {
+			return nil, -1, fmt.Errorf("status not found")
 		}
-		if !rs.OK() {
-			return nil, rs.Err()
+		if !rs.Status.OK() {
+			return nil, -1, rs.Status.Err()
 		}
 
-		return rs, nil
+		return rs, -1, nil
 	}
 
-	if c, ok := it.clients[vt.RefInstance]; ok {
-		rs := c.DataQuery(req)
-		if rs.Status == nil {
-			rs.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_Timeout, "status not found")
+	if rs := it.healthUnavailable(ref.instance); rs != nil {
+		return rs, -1, nil
+	}
+
+	if ref.client != nil {
+		if err := filterRemoteCheck(req.Filter, 0); err != nil {
+			return nil, -1, err
 		}
-		return rs, nil
+		return it.clientCall(ctx, ref, func() *lynkapi.DataResult {
+			return ref.client.DataQuery(req)
+		}), -1, nil
 	}
 
-	return nil, fmt.Errorf("instance (%s) service not found", vt.RefInstance)
+	return nil, -1, fmt.Errorf("instance (%s) service not found", ref.instance)
 }
 
-func (it *LayoutManager) Upsert(req *lynkapi.DataInsert) (*lynkapi.DataResult, error) {
+func (it *LayoutManager) UpsertContext(ctx context.Context, req *lynkapi.DataInsert) (*lynkapi.DataResult, error) {
 
-	it.mu.Lock()
-	defer it.mu.Unlock()
+	// the scope values and the ref table are set to a copy of the request
+	req = proto.Clone(req).(*lynkapi.DataInsert)
 
-	vt, ok := it.tables[req.TableName]
-	if !ok {
-		return nil, fmt.Errorf("table (%s) not found", req.TableName)
+	ref, err := it.tableRef(req.TableName)
+	if err != nil {
+		return nil, err
 	}
-	if vt.RefInstance == "" || vt.RefTable == "" {
-		return nil, fmt.Errorf("ref-table not found")
+	if fr, err := rowScope(ctx, ref); err != nil {
+		return nil, err
+	} else if fr != nil {
+		values := scopeValues(fr)
+		if len(values) == 0 {
+			return nil, lynkapi.NewUnAuthError(fmt.Sprintf("table (%s) : row filter without equal conditions is read only", req.TableName))
+		}
+		if err := scopeInsert(req, values); err != nil {
+			return nil, err
+		}
+		if err := it.scopeRowCheck(ctx, req.TableName, req, values); err != nil {
+			return nil, err
+		}
 	}
-	req.InstanceName = vt.RefInstance
-	req.TableName = vt.RefTable
+	req.InstanceName = ref.instance
+	req.TableName = ref.table
+
+	if ref.service != nil {
 
-	if srv, ok := it.services[vt.RefInstance]; ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-		rs, err := srv.Upsert(req)
+		rs, err := ref.service.Upsert(req)
 		if err != nil {
 			return nil, err
 		}
-		if !rs.OK() {
-			return nil, rs.Err()
+		if rs.Status == nil {
+			return nil, fmt.Errorf("status not found")
+		}
+		if !rs.Status.OK() {
+			return nil, rs.Status.Err()
 		}
 
 		return rs, nil
 	}
 
-	if c, ok := it.clients[vt.RefInstance]; ok {
-		rs := c.DataUpsert(req)
-		if rs.Status == nil {
-			rs.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_Timeout, "status not found")
-		}
+	if rs := it.healthUnavailable(ref.instance); rs != nil {
 		return rs, nil
 	}
 
-	return nil, fmt.Errorf("instance (%s) service not found", vt.RefInstance)
+	if ref.client != nil {
+		return it.clientCall(ctx, ref, func() *lynkapi.DataResult {
+			return ref.client.DataUpsert(req)
+		}), nil
+	}
+
+	return nil, fmt.Errorf("instance (%s) service not found", ref.instance)
+}
+
+// DeleteContext deletes the rows matching the filter and the row scope of
+// the table. The drivers match the primary keys of a delete only, so the
+// rows are first read with the full filter, then deleted one by one by their
+// primary keys. The result has the deleted rows, on a failure also the ones
+// deleted before it.
+func (it *LayoutManager) DeleteContext(ctx context.Context, req *lynkapi.DataDelete) (*lynkapi.DataResult, error) {
+
+	if req.Filter == nil {
+		return nil, fmt.Errorf("filter not setup")
+	}
+
+	ref, err := it.tableRef(req.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.service == nil {
+		if rs := it.healthUnavailable(ref.instance); rs != nil {
+			return rs, nil
+		}
+		if ref.client != nil {
+			// the remote api has no delete method yet
+			return &lynkapi.DataResult{
+				Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_NotImplemented,
+					fmt.Sprintf("instance (%s) does not support row delete", ref.instance)),
+			}, nil
+		}
+		return nil, fmt.Errorf("instance (%s) service not found", ref.instance)
+	}
+
+	filter := req.Filter
+	if fr, err := rowScope(ctx, ref); err != nil {
+		return nil, err
+	} else if fr != nil {
+		filter = filterAnd(filter, fr)
+	}
+
+	spec := it.TableSpec(ref.name)
+	if spec == nil {
+		return nil, fmt.Errorf("table (%s) spec not found", ref.name)
+	}
+
+	rows, err := it.deleteRows(ctx, ref, spec, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, lynkapi.NewNotFoundError("no rows match the filter")
+	}
+
+	rs := &lynkapi.DataResult{
+		Spec: spec,
+	}
+	for _, row := range rows {
+
+		if err := ctx.Err(); err != nil {
+			rs.Status = lynkapi.ParseError(err)
+			return rs, nil
+		}
+
+		del := &lynkapi.DataDelete{
+			InstanceName: ref.instance,
+			TableName:    ref.table,
+			Filter:       deletePkFilter(spec, row),
+		}
+		if del.Filter == nil {
+			rs.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest,
+				fmt.Sprintf("table (%s) : row without primary keys", ref.name))
+			return rs, nil
+		}
+
+		drs, err := ref.service.Delete(del)
+		if err == nil && drs.Status == nil {
+			err = fmt.Errorf("status not found")
+		} else if err == nil && !drs.Status.OK() {
+			err = drs.Status.Err()
+		}
+		if err != nil {
+			rs.Status = lynkapi.ParseError(err)
+			return rs, nil
+		}
+		rs.Rows = append(rs.Rows, row)
+	}
+
+	rs.Status = lynkapi.NewServiceStatusOK()
+	return rs, nil
+}
+
+// deleteRows reads all rows of the filter page by page. The rows are matched
+// against the filter again, a driver may drop the conditions it cannot
+// evaluate.
+func (it *LayoutManager) deleteRows(ctx context.Context, ref *tableRef,
+	spec *lynkapi.TableSpec, filter *lynkapi.DataQuery_Filter) ([]*lynkapi.DataRow, error) {
+
+	var (
+		rows   []*lynkapi.DataRow
+		offset string
+	)
+	for {
+		rs, _, err := it.queryPage(ctx, ref, &lynkapi.DataQuery{
+			Filter: filter,
+			Offset: offset,
+			Limit:  deletePageSize,
+		})
+		if err == nil && rs.Status == nil {
+			err = fmt.Errorf("status not found")
+		} else if err == nil && !rs.Status.OK() {
+			err = rs.Status.Err()
+		}
+		if err != nil {
+			if lynkapi.ParseError(err).Code == lynkapi.StatusCode_NotFound {
+				return rows, nil
+			}
+			return nil, err
+		}
+		for _, row := range rs.Rows {
+			if FilterMatch(filter, rowFields(spec, row)) {
+				rows = append(rows, row)
+			}
+		}
+		if rs.NextOffset == "" || rs.NextOffset == offset || len(rs.Rows) == 0 {
+			return rows, nil
+		}
+		offset = rs.NextOffset
+	}
+}
+
+// deletePkFilter returns the filter of the primary keys of the row, or nil
+// if the table has none or the row misses one.
+func deletePkFilter(spec *lynkapi.TableSpec, row *lynkapi.DataRow) *lynkapi.DataQuery_Filter {
+	var (
+		fields = rowFields(spec, row)
+		inner  []*lynkapi.DataQuery_Filter
+	)
+	for _, field := range spec.Fields {
+		if !field.HasAttr("primary_key") {
+			continue
+		}
+		v, ok := fields[field.TagName]
+		if !ok || scopeValueString(v) == "" {
+			return nil
+		}
+		inner = append(inner, &lynkapi.DataQuery_Filter{
+			Field: field.TagName,
+			Value: v,
+		})
+	}
+	if len(inner) == 0 {
+		return nil
+	}
+	return &lynkapi.DataQuery_Filter{
+		Type:  filterTypeAnd,
+		Inner: inner,
+	}
+}
+
+// rowFields returns the values of the row by field name, of the fields map
+// or of the values in the order of the spec.
+func rowFields(spec *lynkapi.TableSpec, row *lynkapi.DataRow) map[string]*structpb.Value {
+	if len(row.Fields) > 0 || spec == nil {
+		return row.Fields
+	}
+	fields := map[string]*structpb.Value{}
+	for i, field := range spec.Fields {
+		if i < len(row.Values) {
+			fields[field.TagName] = row.Values[i]
+		}
+	}
+	return fields
 }
 
 func (it *LayoutManager) table(name string) *TableActive {
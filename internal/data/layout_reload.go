@@ -0,0 +1,237 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const layoutChangesMax = 32
+
+type clientCloser interface {
+	Close() error
+}
+
+// Reload applies the current content of the layout file. Clients of new or
+// changed instances are connected before the switch, clients of removed
+// instances are retired after it, so requests in flight finish on the
+// previous layout. The registered services are kept as is.
+func (it *LayoutManager) Reload() (*lynkui.LayoutChange, error) {
+
+	it.reloadMu.Lock()
+	defer it.reloadMu.Unlock()
+
+	it.mu.RLock()
+	file := it.file
+	it.mu.RUnlock()
+
+	if file == "" {
+		return nil, fmt.Errorf("layout file not setup")
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var layout lynkui.DataLayout
+	if err = codec.Json.Decode(b, &layout); err != nil {
+		return nil, err
+	}
+	layoutSetup(&layout)
+
+	var (
+		chg = &lynkui.LayoutChange{
+			Created: time.Now().UnixMilli(),
+		}
+		tables    = layoutTables(&layout)
+		instances = map[string]*lynkapi.DataInstance{}
+		connects  = map[string]lynkapi.Client{}
+	)
+
+	// connect the new or changed instances without holding the lock
+	it.mu.RLock()
+	for _, inst := range layout.Instances {
+		if _, ok := it.services[inst.Name]; ok {
+			continue
+		}
+		prev, ok := it.instances[inst.Name]
+		if ok && proto.Equal(prev.Connect, inst.Connect) {
+			continue
+		}
+		if ok {
+			chg.InstancesUpdated = append(chg.InstancesUpdated, inst.Name)
+		} else {
+			chg.InstancesAdded = append(chg.InstancesAdded, inst.Name)
+		}
+		connects[inst.Name] = nil
+	}
+	it.mu.RUnlock()
+
+	for _, inst := range layout.Instances {
+		if _, ok := connects[inst.Name]; !ok || inst.Connect == nil {
+			continue
+		}
+		c, err := instanceConnect(inst)
+		it.healthSet(inst.Name, err)
+		if err != nil {
+			chg.Errors = append(chg.Errors, fmt.Sprintf("instance (%s) connect fail : %s", inst.Name, err.Error()))
+			continue
+		}
+		connects[inst.Name] = c
+	}
+
+	var closes []lynkapi.Client
+
+	it.mu.Lock()
+
+	for i, inst := range layout.Instances {
+		if _, ok := it.services[inst.Name]; ok {
+			inst = it.instances[inst.Name]
+			layout.Instances[i] = inst
+		} else if _, ok := connects[inst.Name]; !ok {
+			// unchanged, keep the known spec
+			if prev, ok := it.instances[inst.Name]; ok && prev.Spec != nil &&
+				(inst.Spec == nil || len(inst.Spec.Tables) == 0) {
+				inst.Spec = prev.Spec
+			}
+		}
+		instances[inst.Name] = inst
+	}
+	for name, inst := range it.instances {
+		if _, ok := instances[name]; ok {
+			continue
+		}
+		if _, ok := it.services[name]; ok {
+			instances[name] = inst
+			layout.Instances = append(layout.Instances, inst)
+			continue
+		}
+		chg.InstancesRemoved = append(chg.InstancesRemoved, name)
+	}
+
+	// a failed connect of a changed instance is retried by the health check
+	for name, c := range it.clients {
+		if _, ok := instances[name]; ok {
+			if _, ok := connects[name]; !ok {
+				continue
+			}
+		}
+		closes = append(closes, c)
+		delete(it.clients, name)
+	}
+	for name, c := range connects {
+		if c != nil {
+			it.clients[name] = c
+		}
+	}
+
+	for name, vt := range tables {
+		if prev, ok := it.tables[name]; !ok {
+			chg.TablesAdded = append(chg.TablesAdded, name)
+		} else if !proto.Equal(prev, vt) {
+			chg.TablesUpdated = append(chg.TablesUpdated, name)
+		}
+	}
+	for name := range it.tables {
+		if _, ok := tables[name]; !ok {
+			chg.TablesRemoved = append(chg.TablesRemoved, name)
+		}
+	}
+
+	it.tables, it.instances = tables, instances
+	it.layout.Tables = layout.Tables
+	it.layout.Connects = layout.Connects
+	it.layout.Instances = layout.Instances
+	it.layout.InstanceOptions = layout.InstanceOptions
+	it.layout.TimeoutMs = layout.TimeoutMs
+
+	for _, v := range [][]string{
+		chg.TablesAdded, chg.TablesUpdated, chg.TablesRemoved,
+		chg.InstancesAdded, chg.InstancesUpdated, chg.InstancesRemoved,
+	} {
+		sort.Strings(v)
+	}
+
+	if !chg.Empty() {
+		it.changes = append(it.changes, chg)
+		if n := len(it.changes); n > layoutChangesMax {
+			it.changes = it.changes[n-layoutChangesMax:]
+		}
+	}
+
+	it.mu.Unlock()
+
+	for _, name := range chg.InstancesRemoved {
+		it.healthDel(name)
+		it.InvalidateSpec(name)
+	}
+	for _, name := range chg.InstancesUpdated {
+		it.InvalidateSpec(name)
+	}
+	for _, inst := range layout.Instances {
+		if inst.Connect == nil {
+			it.healthDel(inst.Name)
+		}
+	}
+
+	for _, c := range closes {
+		clientRetire(c)
+	}
+
+	return chg, nil
+}
+
+// Changes returns the recent reload records, oldest first.
+func (it *LayoutManager) Changes() []*lynkui.LayoutChange {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	return append([]*lynkui.LayoutChange{}, it.changes...)
+}
+
+func instanceConnect(inst *lynkapi.DataInstance) (lynkapi.Client, error) {
+
+	cc := lynkapi.ClientConfig{
+		Addr: inst.Connect.Address,
+	}
+	c, err := cc.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.Spec == nil || len(inst.Spec.Tables) == 0 {
+		rs := c.DataProject(&lynkapi.DataProjectRequest{})
+		if rs.Status.OK() {
+			for _, v := range rs.Instances {
+				if v.Name == inst.Name && v.Spec != nil && len(v.Spec.Tables) > 0 {
+					inst.Spec = v.Spec
+					break
+				}
+			}
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+	"github.com/lynkdb/lynkapi/go/oneobject"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+// testLayout returns a layout manager of the layout file body with the
+// lynkui instance holding the dict rows.
+func testLayout(t *testing.T, body string, rows ...map[string]interface{}) *LayoutManager {
+	t.Helper()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "lynkui_layout.json")
+	if body != "" {
+		if err := os.WriteFile(file, []byte(body), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lm := NewLayoutManager()
+	if err := lm.Init(file, true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lm.Close() })
+
+	var do lynkui.MainObjectSet
+	inst, err := oneobject.NewInstance("lynkui", &do)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst.TableSetup("lynk_dict")
+
+	for _, row := range rows {
+		req := &lynkapi.DataInsert{
+			TableName: "lynk_dict",
+		}
+		for k, v := range row {
+			req.SetField(k, v)
+		}
+		if _, err := inst.Igsert(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := lm.RegisterService(inst); err != nil {
+		t.Fatal(err)
+	}
+	return lm
+}
+
+// testDictIds returns the ids of the rows of the table.
+func testDictIds(t *testing.T, lm *LayoutManager, ctx context.Context, table string) map[string]bool {
+	t.Helper()
+
+	ids := map[string]bool{}
+	rs, err := lm.QueryContext(ctx, &lynkapi.DataQuery{
+		TableName: table,
+		Limit:     100,
+	})
+	if err != nil {
+		if lynkapi.ParseError(err).Code == lynkapi.StatusCode_NotFound {
+			return ids
+		}
+		t.Fatal(err)
+	}
+	if rs.Status != nil && rs.Status.Code == lynkapi.StatusCode_NotFound {
+		return ids
+	}
+	for _, row := range rs.Rows {
+		ids[scopeValueString(rowFields(rs.Spec, row)["id"])] = true
+	}
+	return ids
+}
+
+func testFilterEq(field, value string) *lynkapi.DataQuery_Filter {
+	return &lynkapi.DataQuery_Filter{
+		Field: field,
+		Value: structpb.NewStringValue(value),
+	}
+}
+
+func TestDeleteContextFilter(t *testing.T) {
+
+	lm := testLayout(t, "",
+		map[string]interface{}{"id": "a1", "ns": "a", "name": "one", "display_name": "x"},
+		map[string]interface{}{"id": "b1", "ns": "b", "name": "two", "display_name": "x"},
+	)
+	ctx := context.Background()
+
+	// the fixed filter of a pagelet scoped to ns=a, with the id of a row
+	// out of it
+	_, err := lm.DeleteContext(ctx, &lynkapi.DataDelete{
+		TableName: "lynk_dict",
+		Filter:    filterAnd(testFilterEq("id", "b1"), testFilterEq("ns", "a")),
+	})
+	if err == nil || lynkapi.ParseError(err).Code != lynkapi.StatusCode_NotFound {
+		t.Fatalf("delete out of the scope, want not found, got %v", err)
+	}
+	if ids := testDictIds(t, lm, ctx, "lynk_dict"); !ids["a1"] || !ids["b1"] {
+		t.Fatalf("rows after a rejected delete : %v", ids)
+	}
+
+	rs, err := lm.DeleteContext(ctx, &lynkapi.DataDelete{
+		TableName: "lynk_dict",
+		Filter:    filterAnd(testFilterEq("id", "a1"), testFilterEq("ns", "a")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.Status.OK() || len(rs.Rows) != 1 {
+		t.Fatalf("delete in the scope : %v, rows %d", rs.Status, len(rs.Rows))
+	}
+	if ids := testDictIds(t, lm, ctx, "lynk_dict"); ids["a1"] || !ids["b1"] {
+		t.Fatalf("rows after the delete : %v", ids)
+	}
+
+	// a filter without the primary keys deletes each matching row
+	rs, err = lm.DeleteContext(ctx, &lynkapi.DataDelete{
+		TableName: "lynk_dict",
+		Filter:    testFilterEq("display_name", "x"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.Rows) != 1 {
+		t.Fatalf("delete by display name, rows %d", len(rs.Rows))
+	}
+	if ids := testDictIds(t, lm, ctx, "lynk_dict"); len(ids) != 0 {
+		t.Fatalf("rows after the delete by display name : %v", ids)
+	}
+}
+
+// testScopeLayout returns the layout file body with the dict_own table, the
+// lynk_dict rows of the namespace named as the user.
+func testScopeLayout(t *testing.T) string {
+	t.Helper()
+
+	b, err := codec.Json.Encode(&lynkui.DataLayout{
+		Tables: []*lynkui.DataLayout_VirtualTable{
+			{
+				Name:        "dict_own",
+				RefInstance: "lynkui",
+				RefTable:    "lynk_dict",
+				RowFilter:   testFilterEq("ns", "${user.name}"),
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestDeleteContextRowScope(t *testing.T) {
+
+	lm := testLayout(t, testScopeLayout(t),
+		map[string]interface{}{"id": "a1", "ns": "alice", "name": "one", "display_name": "x"},
+		map[string]interface{}{"id": "b1", "ns": "bob", "name": "two", "display_name": "x"},
+	)
+
+	var (
+		alice = WithUser(context.Background(), &lynkui.User{Name: "alice"})
+		bob   = WithUser(context.Background(), &lynkui.User{Name: "bob"})
+	)
+
+	if ids := testDictIds(t, lm, alice, "dict_own"); len(ids) != 1 || !ids["a1"] {
+		t.Fatalf("rows in the scope of alice : %v", ids)
+	}
+
+	// the row of bob by its primary key, and by a field both rows share
+	for _, fr := range []*lynkapi.DataQuery_Filter{
+		testFilterEq("id", "b1"),
+		testFilterEq("display_name", "x"),
+	} {
+		rs, err := lm.DeleteContext(alice, &lynkapi.DataDelete{
+			TableName: "dict_own",
+			Filter:    fr,
+		})
+		if fr.Field == "id" {
+			if err == nil || lynkapi.ParseError(err).Code != lynkapi.StatusCode_NotFound {
+				t.Fatalf("delete out of the row scope, want not found, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rs.Rows) != 1 || scopeValueString(rowFields(rs.Spec, rs.Rows[0])["id"]) != "a1" {
+			t.Fatalf("delete in the row scope of alice, rows %d", len(rs.Rows))
+		}
+	}
+
+	if ids := testDictIds(t, lm, bob, "dict_own"); len(ids) != 1 || !ids["b1"] {
+		t.Fatalf("rows in the scope of bob after the deletes of alice : %v", ids)
+	}
+	if ids := testDictIds(t, lm, context.Background(), "lynk_dict"); len(ids) != 1 || !ids["b1"] {
+		t.Fatalf("rows after the deletes of alice : %v", ids)
+	}
+
+	// a row scope needs a signed in user
+	if _, err := lm.DeleteContext(context.Background(), &lynkapi.DataDelete{
+		TableName: "dict_own",
+		Filter:    testFilterEq("id", "b1"),
+	}); err == nil {
+		t.Fatal("delete without a user, want an error")
+	}
+}
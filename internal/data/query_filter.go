@@ -0,0 +1,161 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+// the types of the leaf conditions besides the equal type "", these are
+// evaluated by the layout and the audit log only
+const (
+	FilterTypeContains = "contains"
+	FilterTypeGte      = "gte"
+	FilterTypeLte      = "lte"
+
+	filterTypeOr = "or"
+)
+
+// FilterTypes returns the leaf condition types the instance of the table
+// evaluates.
+func (it *LayoutManager) FilterTypes(table string) []string {
+	ref, err := it.tableRef(table)
+	if err != nil || ref.service == nil {
+		return []string{""}
+	}
+	return []string{"", FilterTypeContains, FilterTypeGte, FilterTypeLte}
+}
+
+// filterRemoteCheck rejects the conditions a remote instance does not
+// evaluate, the leaf types other than equal and the nested groups.
+func filterRemoteCheck(fr *lynkapi.DataQuery_Filter, depth int) error {
+	if fr == nil {
+		return nil
+	}
+	if fr.Field == "" && len(fr.Inner) > 0 {
+		if depth > 0 {
+			return lynkapi.NewBadRequestError("nested filter groups not supported by the instance")
+		}
+		switch fr.Type {
+		case "", filterTypeAnd, filterTypeOr:
+		default:
+			return lynkapi.NewBadRequestError(fmt.Sprintf("filter type (%s) not supported", fr.Type))
+		}
+		for _, v := range fr.Inner {
+			if err := filterRemoteCheck(v, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if fr.Type != "" {
+		return lynkapi.NewBadRequestError(
+			fmt.Sprintf("filter type (%s) not supported by the instance", fr.Type))
+	}
+	return nil
+}
+
+// FilterMatch reports whether the field values match the filter. The inner
+// conditions are AND-combined unless the type is "or", a leaf condition
+// without value matches all.
+func FilterMatch(fr *lynkapi.DataQuery_Filter, fields map[string]*structpb.Value) bool {
+
+	if fr == nil {
+		return true
+	}
+
+	if fr.Field == "" {
+		if fr.Type == filterTypeOr && len(fr.Inner) > 0 {
+			for _, v := range fr.Inner {
+				if FilterMatch(v, fields) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, v := range fr.Inner {
+			if !FilterMatch(v, fields) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if fr.Value == nil {
+		return true
+	}
+
+	v, ok := fields[fr.Field]
+	if !ok || v == nil {
+		return false
+	}
+
+	switch fv := v.Kind.(type) {
+
+	case *structpb.Value_NumberValue:
+		var n float64
+		switch rv := fr.Value.Kind.(type) {
+		case *structpb.Value_NumberValue:
+			n = rv.NumberValue
+		case *structpb.Value_StringValue:
+			var err error
+			if n, err = strconv.ParseFloat(rv.StringValue, 64); err != nil {
+				return false
+			}
+		default:
+			return false
+		}
+		switch fr.Type {
+		case "":
+			return fv.NumberValue == n
+		case FilterTypeGte:
+			return fv.NumberValue >= n
+		case FilterTypeLte:
+			return fv.NumberValue <= n
+		}
+
+	case *structpb.Value_StringValue:
+		var s string
+		switch rv := fr.Value.Kind.(type) {
+		case *structpb.Value_StringValue:
+			s = rv.StringValue
+		case *structpb.Value_NumberValue:
+			s = strconv.FormatFloat(rv.NumberValue, 'f', -1, 64)
+		default:
+			return false
+		}
+		switch fr.Type {
+		case "":
+			return fv.StringValue == s
+		case FilterTypeContains:
+			return strings.Contains(strings.ToLower(fv.StringValue), strings.ToLower(s))
+		}
+
+	case *structpb.Value_BoolValue:
+		if fr.Type == "" {
+			if rv, ok := fr.Value.Kind.(*structpb.Value_BoolValue); ok {
+				return fv.BoolValue == rv.BoolValue
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hooto/hlog4g/hlog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+const (
+	// the rows a scanned query reads at most, the rows after it are not
+	// reachable by the offset
+	queryScanMax = 100000
+
+	// the limit of a scanned query without one, as the drivers do
+	queryScanLimitDef = 10
+)
+
+// queryScanDrivers are the in-process drivers which ignore the offset of a
+// query, never set the next offset, sort by numeric fields only and match
+// the equal conditions of string fields only. The layout reads the rows of
+// their tables at once, filters, sorts and pages them itself.
+var queryScanDrivers = map[string]bool{
+	"oneobject": true,
+}
+
+func queryScanDriver(inst *lynkapi.DataInstance) bool {
+	return inst != nil && inst.Spec != nil && queryScanDrivers[inst.Spec.Driver]
+}
+
+// queryScan runs the query of a table of a scanned driver. The offset is the
+// index of the first row of the page, total is the number of matching rows.
+func (it *LayoutManager) queryScan(ctx context.Context, ref *tableRef,
+	req *lynkapi.DataQuery) (*lynkapi.DataResult, int64, error) {
+
+	offset := 0
+	if req.Offset != "" {
+		var err error
+		if offset, err = strconv.Atoi(req.Offset); err != nil || offset < 0 {
+			return nil, 0, lynkapi.NewBadRequestError("invalid offset")
+		}
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = queryScanLimitDef
+	}
+
+	scan := proto.Clone(req).(*lynkapi.DataQuery)
+	scan.Offset, scan.Limit, scan.Sort, scan.Filter = "", queryScanMax, nil, nil
+
+	rs, err := ref.service.Query(scan)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rs.Status == nil {
+		return nil, 0, fmt.Errorf("status not found")
+	}
+	if !rs.Status.OK() {
+		return nil, 0, rs.Status.Err()
+	}
+
+	if len(rs.Rows) >= queryScanMax {
+		hlog.Printf("warn", "table %s scan : truncated at %d rows", ref.name, queryScanMax)
+	}
+
+	if req.Filter != nil {
+		rows := rs.Rows[:0]
+		for _, row := range rs.Rows {
+			if FilterMatch(req.Filter, row.Fields) {
+				rows = append(rows, row)
+			}
+		}
+		rs.Rows = rows
+	}
+
+	if req.Sort != nil && req.Sort.Field != "" {
+		queryScanSort(rs.Rows, req.Sort.Field, req.Sort.Type == "desc")
+	}
+
+	total := len(rs.Rows)
+	if offset >= total {
+		return nil, int64(total), lynkapi.NewNotFoundError("")
+	}
+
+	rows := rs.Rows[offset:]
+	if len(rows) > limit {
+		rows = rows[:limit]
+		rs.NextOffset = strconv.Itoa(offset + limit)
+	}
+	rs.Rows = rows
+
+	return rs, int64(total), nil
+}
+
+// queryScanSort orders the rows by the values of the field, the rows without
+// the field come first.
+func queryScanSort(rows []*lynkapi.DataRow, field string, desc bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := queryValueCompare(rows[i].Fields[field], rows[j].Fields[field])
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func queryValueCompare(a, b *structpb.Value) int {
+	if a == nil || b == nil {
+		switch {
+		case a != nil:
+			return 1
+		case b != nil:
+			return -1
+		}
+		return 0
+	}
+	switch av := a.Kind.(type) {
+	case *structpb.Value_NumberValue:
+		if bv, ok := b.Kind.(*structpb.Value_NumberValue); ok {
+			return cmp.Compare(av.NumberValue, bv.NumberValue)
+		}
+	case *structpb.Value_StringValue:
+		if bv, ok := b.Kind.(*structpb.Value_StringValue); ok {
+			return cmp.Compare(av.StringValue, bv.StringValue)
+		}
+	case *structpb.Value_BoolValue:
+		if bv, ok := b.Kind.(*structpb.Value_BoolValue); ok {
+			switch {
+			case av.BoolValue == bv.BoolValue:
+				return 0
+			case bv.BoolValue:
+				return -1
+			}
+			return 1
+		}
+	}
+	// values of other or mixed kinds are kept in order
+	return 0
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const filterTypeAnd = "and"
+
+var userVarRx = regexp.MustCompile(`\$\{user\.([a-zA-Z0-9_]+)\}`)
+
+type userKey struct{}
+
+// WithUser returns a context carrying the signed in user, the row filters of
+// the virtual tables are resolved against it.
+func WithUser(ctx context.Context, user *lynkui.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+func userFrom(ctx context.Context) *lynkui.User {
+	user, _ := ctx.Value(userKey{}).(*lynkui.User)
+	return user
+}
+
+// HasUserVar reports whether the filter references the user.
+func HasUserVar(fr *lynkapi.DataQuery_Filter) bool {
+	if fr == nil {
+		return false
+	}
+	if fr.Value != nil && userVarRx.MatchString(fr.Value.GetStringValue()) {
+		return true
+	}
+	for _, v := range fr.Inner {
+		if HasUserVar(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveFilter returns a copy of the filter with the ${user.name} and
+// ${user.<attr>} references replaced by the values of the user. It fails if
+// a referenced value is not set, so that the filter never matches more rows
+// than intended.
+func ResolveFilter(fr *lynkapi.DataQuery_Filter, user *lynkui.User) (*lynkapi.DataQuery_Filter, error) {
+
+	if !HasUserVar(fr) {
+		return fr, nil
+	}
+	if user == nil {
+		return nil, lynkapi.NewUnAuthError("row filter requires a signed in user")
+	}
+
+	fr = proto.Clone(fr).(*lynkapi.DataQuery_Filter)
+
+	var resolve func(fr *lynkapi.DataQuery_Filter) error
+	resolve = func(fr *lynkapi.DataQuery_Filter) error {
+		if fr.Value != nil {
+			if _, ok := fr.Value.Kind.(*structpb.Value_StringValue); ok {
+				var err error
+				s := userVarRx.ReplaceAllStringFunc(fr.Value.GetStringValue(), func(m string) string {
+					name := userVarRx.FindStringSubmatch(m)[1]
+					v := user.Attrs[name]
+					if name == "name" {
+						v = user.Name
+					}
+					if v == "" && err == nil {
+						err = lynkapi.NewUnAuthError(fmt.Sprintf("user (%s) attr (%s) not set", user.Name, name))
+					}
+					return v
+				})
+				if err != nil {
+					return err
+				}
+				fr.Value = structpb.NewStringValue(s)
+			}
+		}
+		for _, v := range fr.Inner {
+			if err := resolve(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := resolve(fr); err != nil {
+		return nil, err
+	}
+	return fr, nil
+}
+
+// rowScope resolves the row filter of the table for the user of the context.
+func rowScope(ctx context.Context, ref *tableRef) (*lynkapi.DataQuery_Filter, error) {
+	if ref.filter == nil {
+		return nil, nil
+	}
+	fr, err := ResolveFilter(ref.filter, userFrom(ctx))
+	if err != nil {
+		return nil, lynkapi.NewUnAuthError(fmt.Sprintf("table (%s) : %s",
+			ref.name, lynkapi.ParseError(err).Message))
+	}
+	return fr, nil
+}
+
+func filterAnd(a, b *lynkapi.DataQuery_Filter) *lynkapi.DataQuery_Filter {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	// keep the conditions flat, the services match one level of inner filters
+	var inner []*lynkapi.DataQuery_Filter
+	for _, fr := range []*lynkapi.DataQuery_Filter{a, b} {
+		if fr.Field == "" && len(fr.Inner) > 0 && (fr.Type == filterTypeAnd || fr.Type == "") {
+			inner = append(inner, fr.Inner...)
+		} else {
+			inner = append(inner, fr)
+		}
+	}
+	return &lynkapi.DataQuery_Filter{
+		Type:  filterTypeAnd,
+		Inner: inner,
+	}
+}
+
+// scopeValues returns the field values fixed by the equal conditions of the
+// row filter, these are the values of the rows in scope.
+func scopeValues(fr *lynkapi.DataQuery_Filter) map[string]*structpb.Value {
+	values := map[string]*structpb.Value{}
+	var walk func(fr *lynkapi.DataQuery_Filter)
+	walk = func(fr *lynkapi.DataQuery_Filter) {
+		if fr == nil {
+			return
+		}
+		if len(fr.Inner) > 0 {
+			if fr.Type == filterTypeAnd || fr.Type == "" {
+				for _, v := range fr.Inner {
+					walk(v)
+				}
+			}
+			return
+		}
+		if fr.Field != "" && fr.Type == "" && fr.Value != nil {
+			values[fr.Field] = fr.Value
+		}
+	}
+	walk(fr)
+	return values
+}
+
+func scopeValueString(v *structpb.Value) string {
+	switch v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return v.GetStringValue()
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(v.GetNumberValue(), 'f', -1, 64)
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(v.GetBoolValue())
+	}
+	return ""
+}
+
+// scopeInsert sets the scope values to the request, a request value out of
+// the scope is rejected.
+func scopeInsert(req *lynkapi.DataInsert, values map[string]*structpb.Value) error {
+	for name, value := range values {
+		hit := false
+		for i, field := range req.Fields {
+			if field != name {
+				continue
+			}
+			hit = true
+			if i >= len(req.Values) {
+				continue
+			}
+			if v := scopeValueString(req.Values[i]); v == "" {
+				req.Values[i] = value
+			} else if v != scopeValueString(value) {
+				return lynkapi.NewUnAuthError(fmt.Sprintf("field (%s) value out of the row scope", name))
+			}
+		}
+		if !hit {
+			req.Fields = append(req.Fields, name)
+			req.Values = append(req.Values, value)
+		}
+	}
+	return nil
+}
+
+// scopeRowCheck fails if the row of the primary keys in the request exists
+// out of the scope, an upsert must not take over the rows of other scopes.
+func (it *LayoutManager) scopeRowCheck(ctx context.Context, name string,
+	req *lynkapi.DataInsert, values map[string]*structpb.Value) error {
+
+	spec := it.TableSpec(name)
+	if spec == nil {
+		return fmt.Errorf("table (%s) spec not found", name)
+	}
+
+	var filter *lynkapi.DataQuery_Filter
+	for _, field := range spec.Fields {
+		if !field.HasAttr("primary_key") {
+			continue
+		}
+		i := -1
+		for j, v := range req.Fields {
+			if v == field.TagName && j < len(req.Values) {
+				i = j
+			}
+		}
+		if i < 0 || scopeValueString(req.Values[i]) == "" {
+			// a create, the keys are generated by the service
+			return nil
+		}
+		filter = filterAnd(filter, &lynkapi.DataQuery_Filter{
+			Field: field.TagName,
+			Value: req.Values[i],
+		})
+	}
+	if filter == nil {
+		return nil
+	}
+
+	rs, err := it.queryContext(ctx, &lynkapi.DataQuery{
+		TableName: name,
+		Filter:    filter,
+		Limit:     1,
+	})
+	if err == nil && rs.Status == nil {
+		err = fmt.Errorf("status not found")
+	} else if err == nil && !rs.Status.OK() {
+		err = rs.Status.Err()
+	}
+	if err != nil {
+		if lynkapi.ParseError(err).Code == lynkapi.StatusCode_NotFound {
+			return nil
+		}
+		return err
+	}
+	if len(rs.Rows) == 0 {
+		return nil
+	}
+
+	row := rs.Rows[0]
+	for fname, value := range values {
+		v, ok := row.Fields[fname]
+		if !ok && rs.Spec != nil {
+			for i, f := range rs.Spec.Fields {
+				if f.TagName == fname && i < len(row.Values) {
+					v, ok = row.Values[i], true
+				}
+			}
+		}
+		if !ok || scopeValueString(v) != scopeValueString(value) {
+			return lynkapi.NewUnAuthError("row out of the row scope")
+		}
+	}
+	return nil
+}
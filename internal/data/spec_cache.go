@@ -0,0 +1,179 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hooto/hlog4g/hlog"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+const (
+	specCacheTTL        = 5 * time.Minute
+	specCacheRetryDelay = 10 * time.Second
+)
+
+// specCache holds the data specs fetched from the remote instances.
+type specCache struct {
+	mu    sync.Mutex
+	items map[string]*specEntry
+}
+
+type specEntry struct {
+	spec    *lynkapi.DataSpec
+	err     error // of the last refresh, until it expires
+	expired time.Time
+	refresh chan struct{} // closed once the refresh in flight is done
+}
+
+// remoteSpec returns the cached spec of the remote instance. An expired spec
+// is returned as is while a single refresh runs in the background, callers
+// only wait if nothing was fetched yet. If nothing could be fetched, the
+// error is returned until the retry delay expires.
+func (it *LayoutManager) remoteSpec(name string, c lynkapi.Client, timeout time.Duration) (*lynkapi.DataSpec, error) {
+
+	it.specs.mu.Lock()
+
+	e, ok := it.specs.items[name]
+	if !ok {
+		e = &specEntry{}
+		it.specs.items[name] = e
+	}
+
+	if time.Now().Before(e.expired) {
+		if e.spec != nil {
+			defer it.specs.mu.Unlock()
+			return e.spec, nil
+		}
+		if e.err != nil {
+			defer it.specs.mu.Unlock()
+			return nil, e.err
+		}
+	}
+
+	done := e.refresh
+	if done == nil {
+		done = make(chan struct{})
+		e.refresh = done
+		go it.specRefresh(name, e, c, timeout)
+	}
+
+	if spec := e.spec; spec != nil {
+		it.specs.mu.Unlock()
+		return spec, nil
+	}
+	it.specs.mu.Unlock()
+
+	<-done
+
+	it.specs.mu.Lock()
+	defer it.specs.mu.Unlock()
+	if e.spec == nil {
+		return nil, e.err
+	}
+	return e.spec, nil
+}
+
+func (it *LayoutManager) specRefresh(name string, e *specEntry, c lynkapi.Client, timeout time.Duration) {
+
+	spec, err := specFetch(name, c, timeout)
+
+	it.specs.mu.Lock()
+	defer it.specs.mu.Unlock()
+
+	defer func() {
+		close(e.refresh)
+		e.refresh = nil
+	}()
+
+	if err != nil {
+		hlog.Printf("warn", "instance (%s) spec refresh fail %s", name, err.Error())
+		e.err = err
+		e.expired = time.Now().Add(specCacheRetryDelay)
+		return
+	}
+
+	if e.spec != nil {
+		specChangeLog(name, e.spec, spec)
+	}
+	e.spec, e.err = spec, nil
+	e.expired = time.Now().Add(specCacheTTL)
+}
+
+// InvalidateSpec drops the cached spec of the instance, or of all instances
+// if name is empty, the next TableSpec call fetches it again.
+func (it *LayoutManager) InvalidateSpec(name string) {
+	it.specs.mu.Lock()
+	defer it.specs.mu.Unlock()
+	if name == "" {
+		it.specs.items = map[string]*specEntry{}
+	} else {
+		delete(it.specs.items, name)
+	}
+}
+
+func specFetch(name string, c lynkapi.Client, timeout time.Duration) (*lynkapi.DataSpec, error) {
+
+	ch := make(chan *lynkapi.DataProjectResponse, 1)
+	go func() {
+		ch <- c.DataProject(&lynkapi.DataProjectRequest{})
+	}()
+
+	var rs *lynkapi.DataProjectResponse
+	select {
+	case rs = <-ch:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("request timeout")
+	}
+
+	if rs == nil || rs.Status == nil {
+		return nil, fmt.Errorf("status not found")
+	}
+	if !rs.Status.OK() {
+		return nil, fmt.Errorf("%s", rs.Status.Message)
+	}
+	for _, v := range rs.Instances {
+		if v.Name == name && v.Spec != nil && len(v.Spec.Tables) > 0 {
+			return v.Spec, nil
+		}
+	}
+	return nil, fmt.Errorf("spec not found")
+}
+
+func specChangeLog(name string, prev, spec *lynkapi.DataSpec) {
+
+	tables := map[string]*lynkapi.TableSpec{}
+	for _, t := range prev.Tables {
+		tables[t.Name] = t
+	}
+
+	for _, t := range spec.Tables {
+		if p, ok := tables[t.Name]; !ok {
+			hlog.Printf("info", "instance (%s) table (%s) spec added", name, t.Name)
+		} else if !proto.Equal(p, t) {
+			hlog.Printf("warn", "instance (%s) table (%s) spec changed", name, t.Name)
+		}
+		delete(tables, t.Name)
+	}
+
+	for tn := range tables {
+		hlog.Printf("warn", "instance (%s) table (%s) spec removed", name, tn)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+// testClient is a remote instance answering the project requests with the
+// spec, or with an error if it is nil. The requests hang until block is
+// closed, if it is set.
+type testClient struct {
+	lynkapi.Client
+	name  string
+	spec  *lynkapi.DataSpec
+	block chan struct{}
+	calls atomic.Int32
+}
+
+func (it *testClient) DataProject(req *lynkapi.DataProjectRequest) *lynkapi.DataProjectResponse {
+	it.calls.Add(1)
+	if it.block != nil {
+		<-it.block
+	}
+	if it.spec == nil {
+		return &lynkapi.DataProjectResponse{
+			Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_InternalServerError, "unavailable"),
+		}
+	}
+	return &lynkapi.DataProjectResponse{
+		Status: lynkapi.NewServiceStatusOK(),
+		Instances: []*lynkapi.DataInstance{
+			{Name: it.name, Spec: it.spec},
+		},
+	}
+}
+
+func TestRemoteSpecRetryDelay(t *testing.T) {
+
+	var (
+		lm = NewLayoutManager()
+		c  = &testClient{name: "remote"}
+	)
+
+	for i := 0; i < 3; i++ {
+		if spec, err := lm.remoteSpec("remote", c, time.Second); spec != nil || err == nil {
+			t.Fatalf("spec of an unavailable instance : %v, %v", spec, err)
+		}
+	}
+	if n := c.calls.Load(); n != 1 {
+		t.Fatalf("project requests %d within the retry delay, want 1", n)
+	}
+
+	// the next call after the retry delay fetches again
+	c.spec = &lynkapi.DataSpec{
+		Tables: []*lynkapi.TableSpec{{Name: "t1"}},
+	}
+	lm.specs.mu.Lock()
+	lm.specs.items["remote"].expired = time.Now()
+	lm.specs.mu.Unlock()
+
+	spec, err := lm.remoteSpec("remote", c, time.Second)
+	if err != nil || spec == nil || len(spec.Tables) != 1 {
+		t.Fatalf("spec after the retry delay : %v, %v", spec, err)
+	}
+	if n := c.calls.Load(); n != 2 {
+		t.Fatalf("project requests %d, want 2", n)
+	}
+}
@@ -15,45 +15,91 @@
 package status
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/lynkdb/lynkui/go/lynkui"
 )
 
-var Assets = sets{
-	items:    map[string]interface{}{},
-	pagelets: map[string]*lynkui.Pagelet{},
-}
-
-type sets struct {
+// Sets is the asset registry of one project.
+type Sets struct {
 	mu       sync.Mutex
 	items    map[string]interface{}
 	pagelets map[string]*lynkui.Pagelet
+	builtins map[string]*lynkui.Pagelet
+	errors   map[string][]*lynkui.ValidateError
+}
+
+func NewSets() *Sets {
+	return &Sets{
+		items:    map[string]interface{}{},
+		pagelets: map[string]*lynkui.Pagelet{},
+		builtins: map[string]*lynkui.Pagelet{},
+		errors:   map[string][]*lynkui.ValidateError{},
+	}
 }
 
-func (it *sets) Pagelet(name string) *lynkui.Pagelet {
+// Pagelet returns a copy of the registered pagelet, the caller owns it and
+// may modify it without affecting the registry or concurrent requests.
+func (it *Sets) Pagelet(name string) *lynkui.Pagelet {
 	it.mu.Lock()
 	defer it.mu.Unlock()
 	if pl, ok := it.pagelets[name]; ok {
-		return pl
+		return proto.Clone(pl).(*lynkui.Pagelet)
+	}
+	if pl, ok := it.builtins[name]; ok {
+		return proto.Clone(pl).(*lynkui.Pagelet)
 	}
 	return nil
 }
 
-func (it *sets) SetPagelet(name string, vl *lynkui.Pagelet) {
+// SetBuiltinPagelet registers a pagelet shipped with lynkui, a project
+// pagelet of the same name takes precedence.
+func (it *Sets) SetBuiltinPagelet(name string, vl *lynkui.Pagelet) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.builtins[name] = vl
+}
+
+func (it *Sets) SetPagelet(name string, vl *lynkui.Pagelet) {
 	it.mu.Lock()
 	defer it.mu.Unlock()
 	it.pagelets[name] = vl
 }
 
-func (it *sets) Sync(name string, v interface{}) {
+func (it *Sets) DelPagelet(name string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	delete(it.pagelets, name)
+}
+
+// PageletNames returns the sorted names of all registered pagelets.
+func (it *Sets) PageletNames() []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	names := make([]string, 0, len(it.pagelets)+len(it.builtins))
+	for name := range it.pagelets {
+		names = append(names, name)
+	}
+	for name := range it.builtins {
+		if _, ok := it.pagelets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (it *Sets) Sync(name string, v interface{}) {
 	it.mu.Lock()
 	defer it.mu.Unlock()
 	it.items[strings.TrimLeft(name, "/")] = v
 }
 
-func (it *sets) Get(name string) interface{} {
+func (it *Sets) Get(name string) interface{} {
 	it.mu.Lock()
 	defer it.mu.Unlock()
 	if v, ok := it.items[strings.TrimLeft(name, "/")]; ok {
@@ -61,3 +107,51 @@ func (it *sets) Get(name string) interface{} {
 	}
 	return nil
 }
+
+func (it *Sets) Del(name string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	delete(it.items, strings.TrimLeft(name, "/"))
+}
+
+// List returns the sorted names of all items with the prefix.
+func (it *Sets) List(prefix string) []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	prefix = strings.TrimLeft(prefix, "/")
+	names := []string{}
+	for name := range it.items {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetErrors replaces the validate errors of the file, an empty list clears them.
+func (it *Sets) SetErrors(file string, errs []*lynkui.ValidateError) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if len(errs) == 0 {
+		delete(it.errors, file)
+	} else {
+		it.errors[file] = errs
+	}
+}
+
+// Errors returns the validate errors of all files, ordered by file.
+func (it *Sets) Errors() []*lynkui.ValidateError {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	files := make([]string, 0, len(it.errors))
+	for file := range it.errors {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	errs := []*lynkui.ValidateError{}
+	for _, file := range files {
+		errs = append(errs, it.errors[file]...)
+	}
+	return errs
+}
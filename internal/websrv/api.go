@@ -22,15 +22,26 @@ import (
 	"github.com/hooto/hlog4g/hlog"
 	"github.com/hooto/httpsrv"
 
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
 	"github.com/lynkdb/lynkui/go/lynkui"
 
 	"github.com/lynkdb/lynkui/internal/bindata"
-	"github.com/lynkdb/lynkui/internal/data"
 	"github.com/lynkdb/lynkui/internal/status"
 )
 
 type Pagelet struct {
 	*httpsrv.Controller
+	host    *Host
+	session *authSession
+}
+
+func (c *Pagelet) Init() int {
+	var rc int
+	if c.host, rc = hostInit(c.Controller); rc == 0 {
+		c.session, rc = authInit(c.Controller, c.host)
+	}
+	return rc
 }
 
 func (c Pagelet) FetchAction() {
@@ -39,20 +50,32 @@ func (c Pagelet) FetchAction() {
 
 	name := c.Params.Value("name")
 
-	pl := status.Assets.Pagelet(name)
+	pl := c.host.Assets.Pagelet(name)
 	if pl == nil {
 		hlog.Printf("info", "pagelet (%s) fetch fail : object type error", name)
 		return
 	}
 	// jsonPrint(pl)
 
+	user := sessionUser(c.session)
+	if !c.host.Access.Pagelet(user, name) {
+		hlog.Printf("info", "pagelet (%s) fetch denied", name)
+		c.RenderJson(&pageletErrorResult{
+			Kind:   "Pagelet",
+			Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied"),
+		})
+		return
+	}
+
 	if pl.Datalet != nil && pl.Datalet.TableName != "" {
-		if spec := data.Layout.TableSpec(pl.Datalet.TableName); spec != nil {
+		if spec := dataletTableSpec(c.host.Layout, pl); spec != nil {
 			pl.Datalet.TableSpec = spec
 		}
 	}
 
-	if err := pageletPreRender(name, pl); err != nil {
+	accessPagelet(c.host, user, pl)
+
+	if err := pageletPreRender(c.host.Assets, name, pl); err != nil {
 		hlog.Printf("info", "pagelet (%s) pre-render err %s", name, err.Error())
 		return
 	}
@@ -60,76 +83,73 @@ func (c Pagelet) FetchAction() {
 	c.RenderJson(pl)
 }
 
-func pageletPreRender(plName string, item *lynkui.Pagelet) error {
+type pageletErrorResult struct {
+	Kind   string                 `json:"kind"`
+	Status *lynkapi.ServiceStatus `json:"status"`
+}
+
+func pageletPreRender(assets *status.Sets, plName string, item *lynkui.Pagelet) error {
 	if item.Template == nil {
 		return nil
 	}
 
-	attrExport := func(m map[string]string) string {
-		str := ""
-		for name, value := range m {
-			str += fmt.Sprintf(" %s=\"%s\"", name, value)
-		}
-		return str
-	}
-
 	switch {
 
 	case item.Template.Layout != nil:
-		item.Template.Layout.Refix()
-		str := fmt.Sprintf("<!-- pagelet:%s:tpl:layout -->\n", plName)
-		str += fmt.Sprintf("<div class=\"container-fluid _lynkui-container\">\n")
-		str += fmt.Sprintf("<div class=\"row _lynkui-row%s\"%s>\n",
-			colUnitFilter("lynkui-row-", item.Template.Layout.Width),
-			colCssFilter(item.Template.Layout))
-		for _, v := range item.Template.Layout.Cols {
-			str += fmt.Sprintf("  <div id=\"lynkui-%s\" class=\"_lynkui-col%s%s\"%s>%s</div>\n",
-				v.Name, colUnitFilter("lynkui-col-", v.Width), colClassFilter(v), colCssFilter(v), v.Name)
-		}
-		str += "</div>\n"
-		str += "</div>\n"
-		item.Template.Html = &lynkui.TemplateHtml{
-			Html: str,
+		layout := item.Template.Layout.Refix()
+		if err := layout.Validate(); err != nil {
+			return err
 		}
 
-	case item.Template.Nav != nil:
-
-		navAttr := map[string]string{
-			"id":    "nav-{[=it.name]}",
-			"class": fmt.Sprintf("nav lynkui-nav lynkui-gap-box%s", navClassFilter(item.Template.Nav)),
+		var b htmlBuilder
+		b.comment("pagelet:" + plName + ":tpl:layout").tpl("\n")
+		b.open("div", htmlAttr{name: "class", value: "container-fluid _lynkui-container"}).tpl("\n")
+		b.open("div", colAttrs(layout, "row _lynkui-row"+colUnitFilter("lynkui-row-", layout.Width))...).tpl("\n")
+		for _, v := range layout.Cols {
+			attrs := append([]htmlAttr{{name: "id", value: "lynkui-" + v.Name}},
+				colAttrs(v, "_lynkui-col"+colUnitFilter("lynkui-col-", v.Width)+colClassFilter(v))...)
+			b.tpl("  ").open("div", attrs...).text(v.Name).close("div").tpl("\n")
 		}
+		b.close("div").tpl("\n")
+		b.close("div").tpl("\n")
 
-		liAttr := map[string]string{
-			"id":        "nav-item-{[=row.fields.id]}",
-			"class":     "nav-item lynkui-nav-item",
-			"x_pagelet": plName,
-			"x_dict":    "{[=row.x_dict]}",
+		item.Template.Html = &lynkui.TemplateHtml{
+			Html: b.String(),
 		}
 
-		aAttr := map[string]string{
-			"id":    "nav-link-{[=it.name]}-{[=row.fields.id]}",
-			"class": "nav-link lynkui-nav-link",
-			"href":  "#{[=row.fields.name]}",
+	case item.Template.Nav != nil:
+		if err := item.Template.Nav.Validate(); err != nil {
+			return err
 		}
 
-		str := fmt.Sprintf("<!-- pagelet:%s:tpl:nav -->\n", plName)
-
-		str += "<nav" + attrExport(navAttr) + ">\n" +
-			"{[~it.rows :row]}\n" +
-			"<li" + attrExport(liAttr) + ">\n" +
-			"  <a" + attrExport(aAttr) + ">{[=row.fields.display_name]}</a>\n" +
-			"</li>\n" +
-			"{[~]}\n" +
-			"</nav>\n"
+		var b htmlBuilder
+		b.comment("pagelet:" + plName + ":tpl:nav").tpl("\n")
+		b.open("nav",
+			htmlAttr{name: "id", value: "nav-{[!it.name]}", tpl: true},
+			htmlAttr{name: "class", value: "nav lynkui-nav lynkui-gap-box" + navClassFilter(item.Template.Nav)},
+		).tpl("\n{[~it.rows :row]}\n")
+		b.open("li",
+			htmlAttr{name: "id", value: "nav-item-{[!row.fields.id]}", tpl: true},
+			htmlAttr{name: "class", value: "nav-item lynkui-nav-item"},
+			htmlAttr{name: "x_pagelet", value: plName},
+			htmlAttr{name: "x_dict", value: "{[!row.x_dict]}", tpl: true},
+		).tpl("\n  ")
+		b.open("a",
+			htmlAttr{name: "id", value: "nav-link-{[!it.name]}-{[!row.fields.id]}", tpl: true},
+			htmlAttr{name: "class", value: "nav-link lynkui-nav-link"},
+			htmlAttr{name: "href", value: "#{[!row.fields.name]}", tpl: true},
+		).tpl("{[!row.fields.display_name]}").close("a").tpl("\n")
+		b.close("li").tpl("\n{[~]}\n")
+		b.close("nav").tpl("\n")
 
 		item.Template.Html = &lynkui.TemplateHtml{
-			Html: str,
+			Html: b.String(),
 		}
 
 	case item.Template.Html != nil && item.Template.Html.Html == "":
 		if b, err := bindata.Assets.ReadFile("/lynkui/tpl/" + item.Template.Html.File); err == nil {
 			item.Template.Html.Html = string(b)
-		} else if tpl := status.Assets.Get("lynkui/tpl/" + item.Template.Html.File); tpl != nil {
+		} else if tpl := assets.Get("lynkui/tpl/" + item.Template.Html.File); tpl != nil {
 			if h, ok := tpl.(*lynkui.TemplateHtml); ok {
 				item.Template.Html.Html = h.Html
 			}
@@ -154,27 +174,23 @@ func colUnitFilter(prefix, v string) string {
 }
 
 func colClassFilter(c *lynkui.TemplateLayout) string {
+	if ls := c.StyleClasses(); len(ls) > 0 {
+		return " " + strings.Join(ls, " ")
+	}
+	return ""
+}
 
-	var (
-		as = ""
-		ar = strings.Split(c.StyleClass, ",")
-	)
-	for _, v := range ar {
-		v = strings.TrimSpace(v)
-		if v == "" {
-			continue
-		}
-		if as == "" {
-			as += " "
-		} else {
-			as += ","
-		}
-		as += v
+// colAttrs returns the class and style attributes of the layout.
+func colAttrs(c *lynkui.TemplateLayout, class string) []htmlAttr {
+	attrs := []htmlAttr{{name: "class", value: class}}
+	if css := colCss(c); css != "" {
+		attrs = append(attrs, htmlAttr{name: "style", value: css})
 	}
-	return as
+	return attrs
 }
 
-func colCssFilter(c *lynkui.TemplateLayout) string {
+// colCss returns the width and height declarations of the layout.
+func colCss(c *lynkui.TemplateLayout) string {
 
 	var (
 		css = []string{}
@@ -188,7 +204,7 @@ func colCssFilter(c *lynkui.TemplateLayout) string {
 	unitFilter := func(s string) []string {
 		if strings.HasSuffix(s, ")") {
 			if n := strings.Index(s, "("); n > 0 {
-				if _, ok := fun[s[:n]]; ok {
+				if _, ok := fun[s[:n]]; ok && colCssFuncValid(s[n:]) {
 					return []string{s}
 				}
 			}
@@ -247,5 +263,19 @@ func colCssFilter(c *lynkui.TemplateLayout) string {
 		return ""
 	}
 
-	return " style=\"" + strings.Join(css, ";") + ";\""
+	return strings.Join(css, ";") + ";"
+}
+
+// colCssFuncValid reports whether the arguments of a calc, min or max value
+// are made of lengths and operators only.
+func colCssFuncValid(s string) bool {
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case strings.ContainsRune("%.+-*/(), ", c):
+		default:
+			return false
+		}
+	}
+	return true
 }
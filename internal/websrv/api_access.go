@@ -0,0 +1,145 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/access"
+)
+
+// the field attr set on the read-only fields of the specs sent to the
+// browser, main.js shows them as read-only in the upsert form
+const accessReadOnlyAttr = "read_only"
+
+func sessionUser(s *authSession) *lynkui.User {
+	if s == nil {
+		return nil
+	}
+	return s.user
+}
+
+// accessSpec returns a copy of the spec without the hidden fields, and with
+// the read-only fields marked.
+func accessSpec(spec *lynkapi.TableSpec, g *access.TableGrant) *lynkapi.TableSpec {
+	if spec == nil || (len(g.Hidden) == 0 && len(g.ReadOnly) == 0) {
+		return spec
+	}
+	spec = proto.Clone(spec).(*lynkapi.TableSpec)
+	spec.Fields = slices.DeleteFunc(spec.Fields, func(field *lynkapi.FieldSpec) bool {
+		return g.Hidden[field.TagName]
+	})
+	for _, field := range spec.Fields {
+		if g.ReadOnly[field.TagName] && !slices.Contains(field.Attrs, accessReadOnlyAttr) {
+			field.Attrs = append(field.Attrs, accessReadOnlyAttr)
+		}
+	}
+	return spec
+}
+
+// accessResult drops the hidden fields of the result spec and rows.
+func accessResult(rs *lynkapi.DataResult, g *access.TableGrant) {
+	if len(g.Hidden) == 0 {
+		return
+	}
+	var idx []int
+	if rs.Spec != nil {
+		for i, field := range rs.Spec.Fields {
+			if g.Hidden[field.TagName] {
+				idx = append(idx, i)
+			}
+		}
+		rs.Spec = accessSpec(rs.Spec, &access.TableGrant{Hidden: g.Hidden})
+	}
+	// the rows of local services may be shared with their store
+	rows := make([]*lynkapi.DataRow, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		row = proto.Clone(row).(*lynkapi.DataRow)
+		for name := range g.Hidden {
+			delete(row.Fields, name)
+		}
+		if len(row.Values) > 0 {
+			var values []*structpb.Value
+			for i, v := range row.Values {
+				if !slices.Contains(idx, i) {
+					values = append(values, v)
+				}
+			}
+			row.Values = values
+		}
+		rows = append(rows, row)
+	}
+	rs.Rows = rows
+}
+
+// accessNavRows drops the nav rows targeting a pagelet the user may not
+// open, the target is set in ext_fields.pagelet of the dict rows.
+func (c Datalet) accessNavRows(rs *lynkapi.DataResult) {
+	if !c.host.Access.Enabled() {
+		return
+	}
+	var (
+		user = sessionUser(c.session)
+		rows = make([]*lynkapi.DataRow, 0, len(rs.Rows))
+	)
+	for _, row := range rs.Rows {
+		if v, ok := row.Fields["ext_fields"].GetStructValue().GetFields()["pagelet"]; ok &&
+			v.GetStringValue() != "" && !c.host.Access.Pagelet(user, v.GetStringValue()) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	rs.Rows = rows
+}
+
+// accessPagelet prunes the pagelet for the user before it is sent to the
+// browser: the spec fields, the data change flags and the links to the
+// pagelets the user may not open.
+func accessPagelet(h *Host, user *lynkui.User, pl *lynkui.Pagelet) {
+
+	if pl.Datalet != nil && pl.Datalet.TableName != "" {
+		g := h.Access.Table(user, pl.Datalet.TableName)
+		if !g.Read {
+			pl.Datalet.TableSpec = nil
+		} else {
+			pl.Datalet.TableSpec = accessSpec(pl.Datalet.TableSpec, g)
+		}
+		if !g.Write {
+			pl.ExpDataCreateEnable = false
+			pl.ExpDataUpdateEnable = false
+			pl.ExpDataDeleteEnable = false
+		}
+	}
+
+	pl.NextPagelets = slices.DeleteFunc(pl.NextPagelets, func(v *lynkui.Pagelet_Next) bool {
+		return !h.Access.Pagelet(user, v.Name)
+	})
+
+	if pl.Event != nil && pl.Event.Pagelet != "" && !h.Access.Pagelet(user, pl.Event.Pagelet) {
+		pl.Event = nil
+	}
+
+	if pl.Template != nil && pl.Template.Nav != nil {
+		pl.Template.Nav.Items = slices.DeleteFunc(pl.Template.Nav.Items, func(v *lynkui.TemplateNav_Item) bool {
+			return !h.Access.Pagelet(user, v.Name)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hooto/hlog4g/hlog"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/internal/audit"
+)
+
+// auditRecord appends the change to the audit trail. A failure is logged
+// only, the change itself is already done.
+func (c Datalet) auditRecord(e *audit.Entry) {
+	if c.host.Audit == nil {
+		return
+	}
+	if user := sessionUser(c.session); user != nil {
+		e.User = user.Name
+	}
+	if err := c.host.Audit.Record(e); err != nil {
+		hlog.Printf("warn", "audit %s %s %s : %s", e.Op, e.TableName, e.Pk, err.Error())
+	}
+}
+
+// auditRows returns the rows matched by the filter, with the values keyed
+// by the field tag names. They are read before a change to record the
+// previous values, and to tell an update from a create.
+func (c Datalet) auditRows(table string, filter *lynkapi.DataQuery_Filter, limit int64) []map[string]*structpb.Value {
+
+	if filter == nil {
+		return nil
+	}
+
+	rs, err := c.host.Layout.QueryContext(c.dataContext(), &lynkapi.DataQuery{
+		TableName: table,
+		Filter:    filter,
+		Limit:     limit,
+	})
+	if err != nil || rs.Status == nil || !rs.Status.OK() {
+		return nil
+	}
+
+	var rows []map[string]*structpb.Value
+	for _, row := range rs.Rows {
+		rows = append(rows, dataRowFields(rs.Spec, row))
+	}
+	return rows
+}
+
+// auditPkFilter returns the filter on the primary keys set in the fields, or
+// nil if any of them is not set.
+func auditPkFilter(spec *lynkapi.TableSpec, fields map[string]*structpb.Value) *lynkapi.DataQuery_Filter {
+	var filters []*lynkapi.DataQuery_Filter
+	for _, field := range spec.Fields {
+		if !field.HasAttr("primary_key") {
+			continue
+		}
+		v, ok := fields[field.TagName]
+		if !ok || dataletUpsertEmpty(v) {
+			return nil
+		}
+		filters = append(filters, &lynkapi.DataQuery_Filter{
+			Field: field.TagName,
+			Value: v,
+		})
+	}
+	return dataletFilterMerge(filters...)
+}
+
+// auditPkRow returns the row of the table with the primary keys set in the
+// fields, or nil if there is none. The keys of the returned row are checked,
+// a service may drop the filters it cannot match and return other rows.
+func (c Datalet) auditPkRow(table string, spec *lynkapi.TableSpec,
+	fields map[string]*structpb.Value) map[string]*structpb.Value {
+
+	rows := c.auditRows(table, auditPkFilter(spec, fields), 1)
+	if len(rows) != 1 || auditPk(spec, rows[0]) != auditPk(spec, fields) {
+		return nil
+	}
+	return rows[0]
+}
+
+// auditPk formats the primary key values as "name=value", joined by commas.
+func auditPk(spec *lynkapi.TableSpec, fields map[string]*structpb.Value) string {
+	var keys []string
+	for _, field := range spec.Fields {
+		if !field.HasAttr("primary_key") {
+			continue
+		}
+		v, ok := fields[field.TagName]
+		if !ok {
+			continue
+		}
+		s := v.GetStringValue()
+		if _, ok := v.GetKind().(*structpb.Value_StringValue); !ok {
+			js, _ := json.Marshal(v.AsInterface())
+			s = string(js)
+		}
+		keys = append(keys, field.TagName+"="+s)
+	}
+	return strings.Join(keys, ",")
+}
+
+func auditValues(fields map[string]*structpb.Value) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	m := map[string]interface{}{}
+	for k, v := range fields {
+		m[k] = v.AsInterface()
+	}
+	js, _ := json.Marshal(m)
+	return string(js)
+}
+
+func auditFields(names []string, values []*structpb.Value) map[string]*structpb.Value {
+	fields := map[string]*structpb.Value{}
+	for i, name := range names {
+		if i < len(values) {
+			fields[name] = values[i]
+		}
+	}
+	return fields
+}
@@ -15,33 +15,53 @@
 package websrv
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/hooto/hlog4g/hlog"
 	"github.com/hooto/httpsrv"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/lynkdb/lynkapi/go/lynkapi"
 
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/audit"
 	"github.com/lynkdb/lynkui/internal/data"
-	"github.com/lynkdb/lynkui/internal/status"
 )
 
 type Datalet struct {
 	*httpsrv.Controller
+	host    *Host
+	session *authSession
 }
 
+func (c *Datalet) Init() int {
+	var rc int
+	if c.host, rc = hostInit(c.Controller); rc == 0 {
+		c.session, rc = authInit(c.Controller, c.host)
+	}
+	return rc
+}
+
+const (
+	dataletPageSizeDef = 20
+	dataletPageSizeMax = 1000
+)
+
 func (c Datalet) RunAction() {
 	c.AutoRender = false
 	c.Response.Out.Header().Set("Cache-Control", "no-cache")
 
-	var rsp lynkapi.DataResults
+	var rsp dataletResults
 	defer c.RenderJson(&rsp)
 
 	var (
 		name = c.Params.Value("pagelet")
 	)
 
-	pl := status.Assets.Pagelet(name)
+	pl := c.host.Assets.Pagelet(name)
 	if pl == nil {
 		hlog.Printf("info", "pagelet fetch %s fail", name)
 		return
@@ -51,50 +71,180 @@ func (c Datalet) RunAction() {
 		return
 	}
 
-	if pl.Datalet.Query == nil {
-		pl.Datalet.Query = &lynkapi.DataQuery{}
+	rsp.Kind = "DataResults"
+
+	user := sessionUser(c.session)
+	g := c.host.Access.Table(user, pl.Datalet.TableName)
+	if !c.host.Access.Pagelet(user, name) || !g.Read {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied")
+		return
+	}
+
+	pl, err := dataletUserFilter(pl, user)
+	if err != nil {
+		hlog.Printf("warn", "pagelet %s : %s", name, err.Error())
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied")
+		return
+	}
+
+	query, err := dataletQuery(c.host.Layout, pl, g, c.Params)
+	if err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		return
+	}
+
+	hlog.Printf("info", "query %s", string(jsonEncode(query)))
+	ds, total, err := c.host.Layout.QueryPageContext(c.dataContext(), query)
+	if err != nil {
+		hlog.Printf("info", "fetch instance client fail %s", err.Error())
+		// a rejected query is reported, no rows is not an error
+		if status := lynkapi.ParseError(err); status.Code == lynkapi.StatusCode_BadRequest {
+			rsp.Status = status
+		}
+	} else {
+
+		ds2 := &lynkapi.DataResult{
+			Name:   name,
+			Status: ds.Status,
+		}
+
+		item := &dataletResult{
+			DataResult: ds2,
+		}
+		if total > 0 {
+			item.Total = total
+		}
+		item.FilterTypes = c.host.Layout.FilterTypes(pl.Datalet.TableName)
+
+		if ds2.Status.OK() && len(ds.Rows) > 0 {
+			ds2.Spec, ds2.Rows = ds.Spec, ds.Rows
+			ds2.NextOffset = ds.NextOffset
+			// the versions of the full rows, the query of the datalet
+			// may select some of the fields only
+			if pl.ExpDataUpdateEnable && g.Write && len(query.Fields) == 0 {
+				item.RowVersions = dataRowVersions(ds2)
+			}
+			accessResult(ds2, g)
+			c.accessNavRows(ds2)
+		}
+
+		rsp.Results = append(rsp.Results, item)
+	}
+}
+
+// dataletParams are the query params of a datalet request, httpsrv.Params
+// or the params of a server rendered pagelet.
+type dataletParams interface {
+	Value(name string) string
+}
+
+// dataletQuery builds the query of a datalet request. The result is request
+// scoped, the pagelet and its Datalet.Query are never modified.
+func dataletQuery(layout *data.LayoutManager, pl *lynkui.Pagelet, g *access.TableGrant, params dataletParams) (*lynkapi.DataQuery, error) {
+
+	// the fields hidden by the grant can neither be filtered nor sorted
+	spec := accessSpec(layout.TableSpec(pl.Datalet.TableName), g)
+
+	query := &lynkapi.DataQuery{}
+	if pl.Datalet.Query != nil {
+		query = proto.Clone(pl.Datalet.Query).(*lynkapi.DataQuery)
 	}
 
-	if pl.Datalet.Filter != nil {
-		pl.Datalet.Query.Filter = pl.Datalet.Filter
-	} else if pv := c.Params.Value("query_filter"); pv != "" {
+	filters := []*lynkapi.DataQuery_Filter{
+		pl.Datalet.Filter,
+	}
+	if pl.Datalet.List != nil {
+		filters = append(filters, pl.Datalet.List.Filter)
+	}
+
+	if pv := params.Value("query_filter"); pv != "" {
 		if js := base64Decode(pv); js != "" {
 			var queryFilter lynkapi.DataQuery_Filter
 			if err := jsonDecode([]byte(js), &queryFilter); err == nil {
-				pl.Datalet.Query.Filter = &queryFilter
+				if field := dataletFilterHidden(&queryFilter, g); field != "" {
+					return nil, fmt.Errorf("filter field (%s) not found", field)
+				}
+				filters = append(filters, &queryFilter)
 			}
 		}
 	}
 
-	pl.Datalet.Query.TableName = pl.Datalet.TableName
+	if pv := params.Value("query_filters"); pv != "" {
+		items, err := dataletFilterParse(spec, layout.FilterTypes(pl.Datalet.TableName), pv)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, items...)
+	}
 
-	rsp.Kind = "DataResults"
+	query.Filter = dataletFilterMerge(filters...)
 
-	switch {
-	case pl.Datalet.Query != nil:
+	query.TableName = pl.Datalet.TableName
 
-		if pl.Datalet.List != nil && pl.Datalet.List.Sort != nil {
-			pl.Datalet.Query.Sort = pl.Datalet.List.Sort
-		}
+	query.Limit = dataletPageSize(pl)
+	query.Offset = ""
+	if pv := params.Value("offset"); pv != "" && len(pv) <= 1024 {
+		query.Offset = pv
+	}
 
-		hlog.Printf("info", "query %s", string(jsonEncode(pl.Datalet.Query)))
-		ds, err := data.Layout.Query(pl.Datalet.Query)
-		if err != nil {
-			hlog.Printf("info", "fetch instance client fail %s", err.Error())
-		} else {
+	if sort := dataletSortFilter(spec, params.Value("sort_field"), params.Value("sort_type")); sort != nil {
+		query.Sort = sort
+	} else if pl.Datalet.List != nil && pl.Datalet.List.Sort != nil {
+		query.Sort = pl.Datalet.List.Sort
+	}
 
-			ds2 := &lynkapi.DataResult{
-				Name:   name,
-				Status: ds.Status,
-			}
+	return query, nil
+}
 
-			if ds2.Status.OK() && len(ds.Rows) > 0 {
-				ds2.Spec, ds2.Rows = ds.Spec, ds.Rows
-			}
+func dataletSortFilter(spec *lynkapi.TableSpec, field, typ string) *lynkapi.DataQuery_SortFilter {
+	if field == "" {
+		return nil
+	}
+	switch typ {
+	case "", "asc", "desc":
+	default:
+		return nil
+	}
+	if spec == nil {
+		return nil
+	}
+	if fd, _ := spec.Field(field); fd == nil || !dataletSortable(fd) {
+		return nil
+	}
+	if typ == "" {
+		typ = "asc"
+	}
+	return &lynkapi.DataQuery_SortFilter{
+		Type:  typ,
+		Field: field,
+	}
+}
 
-			rsp.Results = append(rsp.Results, ds2)
-		}
+// dataletSortable reports whether the rows can be ordered by the field, the
+// fields of structured values are not comparable.
+func dataletSortable(field *lynkapi.FieldSpec) bool {
+	switch field.Type {
+	case lynkapi.FieldSpec_Bool, lynkapi.FieldSpec_Int, lynkapi.FieldSpec_Uint,
+		lynkapi.FieldSpec_Float, lynkapi.FieldSpec_String,
+		lynkapi.FieldSpec_StringTerm, lynkapi.FieldSpec_StringText:
+		return true
 	}
+	return false
+}
+
+func dataletPageSize(pl *lynkui.Pagelet) int64 {
+	n := int64(0)
+	if pl.Datalet.List != nil && pl.Datalet.List.PageSize > 0 {
+		n = pl.Datalet.List.PageSize
+	} else if pl.Datalet.Query != nil && pl.Datalet.Query.Limit > 0 {
+		n = pl.Datalet.Query.Limit
+	}
+	if n <= 0 {
+		n = dataletPageSizeDef
+	} else if n > dataletPageSizeMax {
+		n = dataletPageSizeMax
+	}
+	return n
 }
 
 func (c Datalet) DictQueryAction() {
@@ -103,9 +253,24 @@ func (c Datalet) DictQueryAction() {
 	var rsp lynkapi.DataResults
 	defer c.RenderJson(&rsp)
 
+	pl, _, rc := dataletWritable(c.host, sessionUser(c.session), c.Params.Value("pagelet"), "")
+	if rc != "" {
+		hlog.Printf("info", "dict-query pagelet %s denied", c.Params.Value("pagelet"))
+		return
+	}
+
+	// only the namespaces of the fields the pagelet edits are readable
+	var allowed []string
+	if spec := dataletTableSpec(c.host.Layout, pl); spec != nil {
+		for _, field := range spec.Fields {
+			allowed = append(allowed, field.DictNs...)
+		}
+	}
+
 	var nsArr = strings.Split(c.Params.Value("namespaces"), ",")
 	for _, ns := range nsArr {
-		if !lynkapi.NamespaceIdentifier.MatchString(ns) {
+		if !lynkapi.NamespaceIdentifier.MatchString(ns) ||
+			!slices.Contains(allowed, ns) {
 			continue
 		}
 		req := &lynkapi.DataQuery{
@@ -116,7 +281,7 @@ func (c Datalet) DictQueryAction() {
 			},
 			Limit: 10000,
 		}
-		ds, err := data.Layout.Query(req)
+		ds, err := c.host.Layout.QueryContext(c.dataContext(), req)
 		if err != nil {
 			hlog.Printf("info", "fetch instance client fail %s", err.Error())
 		} else {
@@ -135,25 +300,187 @@ func (c Datalet) DictQueryAction() {
 	}
 }
 
+// dataletWritable returns the pagelet if it exposes its datalet table for
+// the operation ("create", "update", "delete"), or any of create and update
+// if op is empty, and the user may write to the table. Otherwise a status
+// code is returned.
+func dataletWritable(h *Host, user *lynkui.User, name, op string) (*lynkui.Pagelet, *access.TableGrant, string) {
+
+	pl := h.Assets.Pagelet(name)
+	if pl == nil || pl.Datalet == nil || pl.Datalet.TableName == "" {
+		return nil, nil, lynkapi.StatusCode_NotFound
+	}
+
+	if !h.Access.Pagelet(user, name) {
+		return nil, nil, lynkapi.StatusCode_UnAuth
+	}
+	g := h.Access.Table(user, pl.Datalet.TableName)
+	if !g.Write {
+		return nil, nil, lynkapi.StatusCode_UnAuth
+	}
+
+	var ok bool
+	switch op {
+	case "create":
+		ok = pl.ExpDataCreateEnable
+	case "update":
+		ok = pl.ExpDataUpdateEnable
+	case "delete":
+		ok = pl.ExpDataDeleteEnable
+	case "":
+		ok = pl.ExpDataCreateEnable || pl.ExpDataUpdateEnable
+	}
+	if !ok {
+		return nil, nil, lynkapi.StatusCode_UnAuth
+	}
+
+	pl, err := dataletUserFilter(pl, user)
+	if err != nil {
+		hlog.Printf("warn", "pagelet %s : %s", name, err.Error())
+		return nil, nil, lynkapi.StatusCode_UnAuth
+	}
+
+	return pl, g, ""
+}
+
+// UpsertAction writes a row to the datalet table of the pagelet named by the
+// pagelet param. The table and the fields fixed by the pagelet filters are
+// derived from the pagelet, not from the request.
 func (c Datalet) UpsertAction() {
 	c.AutoRender = false
 	c.Response.Out.Header().Set("Cache-Control", "no-cache")
 
 	var (
-		req lynkapi.DataInsert
-		rsp lynkapi.DataResult
+		req dataletUpsertRequest
+		rsp = dataletUpsertResult{
+			Kind: "DataUpsert",
+		}
+		name = c.Params.Value("pagelet")
+	)
+	defer c.RenderJson(&rsp)
+
+	if !authCsrfCheck(c.Controller, c.session) {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid csrf token")
+		return
+	}
+
+	if err := c.Request.JsonDecode(&req); err != nil || req.DataInsert == nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "invalid request")
+		return
+	}
+
+	w, status := c.dataletWriteSetup(name)
+	if status != nil {
+		rsp.Status = status
+		return
+	}
+
+	if req.TableName != "" && req.TableName != w.pl.Datalet.TableName {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest,
+			fmt.Sprintf("table (%s) does not match the pagelet", req.TableName))
+		return
+	}
+
+	op, status, errs := c.dataletUpsertPrepare(w, req.DataInsert)
+	if status != nil {
+		rsp.Status, rsp.Errors = status, errs
+		return
+	}
+
+	if op == audit.OpUpdate && req.Version != "" {
+		if rsp.Status, rsp.Current, rsp.Version = c.dataletVersionCheck(w,
+			req.DataInsert, req.Version); rsp.Status != nil {
+			return
+		}
+	}
+
+	_, rs, err := c.dataletUpsertRun(w, req.DataInsert, op)
+	if err != nil {
+		rsp.Status = lynkapi.ParseError(err)
+		return
+	}
+	rsp.Status, rsp.Spec, rsp.Rows = rs.Status, rs.Spec, rs.Rows
+
+	// the version of the written row, for the next update of the client
+	if rs.Status != nil && rs.Status.OK() && req.Version != "" {
+		if row := c.auditPkRow(w.pl.Datalet.TableName, w.spec,
+			auditFields(req.Fields, req.Values)); row != nil {
+			rsp.Version = dataRowVersion(row)
+		}
+	}
+}
+
+// DeleteAction deletes rows of the datalet table of the pagelet named by the
+// pagelet param, within the scope of the pagelet filters.
+func (c Datalet) DeleteAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	var (
+		req  lynkapi.DataDelete
+		rsp  lynkapi.DataResult
+		name = c.Params.Value("pagelet")
 	)
 	defer c.RenderJson(&rsp)
 
+	if !authCsrfCheck(c.Controller, c.session) {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid csrf token")
+		return
+	}
+
 	if err := c.Request.JsonDecode(&req); err != nil {
 		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
 		return
 	}
 
-	rs, err := data.Layout.Upsert(&req)
+	if req.Filter == nil || (req.Filter.Field == "" && len(req.Filter.Inner) == 0) {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "filter not setup")
+		return
+	}
+
+	pl, g, rc := dataletWritable(c.host, sessionUser(c.session), name, "delete")
+	if rc != "" {
+		rsp.Status = lynkapi.NewServiceStatus(rc,
+			fmt.Sprintf("pagelet (%s) does not allow data delete", name))
+		return
+	}
+
+	if field := dataletFilterHidden(req.Filter, g); field != "" {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest,
+			fmt.Sprintf("filter field (%s) not found", field))
+		return
+	}
+
+	if req.TableName != "" && req.TableName != pl.Datalet.TableName {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest,
+			fmt.Sprintf("table (%s) does not match the pagelet", req.TableName))
+		return
+	}
+	req.InstanceName, req.TableName = "", pl.Datalet.TableName
+
+	fixed, err := dataletScope(pl, c.Params)
+	if err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		return
+	}
+	req.Filter = dataletScopeFilter(req.Filter, fixed)
+
+	rs, err := c.host.Layout.DeleteContext(c.dataContext(), &req)
 	if err != nil {
 		rsp.Status = lynkapi.ParseError(err)
-	} else {
-		rsp.Status, rsp.Spec, rsp.Rows = rs.Status, rs.Spec, rs.Rows
+		return
+	}
+	rsp.Status = rs.Status
+
+	// the rows deleted, also the ones before a failure
+	for _, row := range rs.Rows {
+		fields := dataRowFields(rs.Spec, row)
+		c.auditRecord(&audit.Entry{
+			Pagelet:   name,
+			TableName: pl.Datalet.TableName,
+			Op:        audit.OpDelete,
+			Pk:        auditPk(rs.Spec, fields),
+			Before:    auditValues(fields),
+		})
 	}
 }
@@ -0,0 +1,347 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/hooto/hlog4g/hlog"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+const (
+	// rows of one export, a larger table is exported in parts by filters
+	dataletExportRowsMax = 1000000
+
+	// the HTTP trailer of an export, "ok" if all rows are sent, otherwise
+	// the error which ended the export
+	dataletExportStatusHeader = "X-Lynkui-Export-Status"
+
+	// the key of the last JSON line of an export which did not send all rows
+	dataletExportErrorKey = "_export_error"
+)
+
+// ExportAction streams all rows of the pagelet query as CSV or JSON lines.
+// The query is the one of RunAction, it is run page by page until the last
+// page. The columns are the display fields of the list, or all readable
+// fields. An export cut by the row limit or by a failed page ends with an
+// error marker, the dataletExportStatusHeader trailer reports the result.
+func (c Datalet) ExportAction() {
+	c.AutoRender = false
+
+	var (
+		name   = c.Params.Value("pagelet")
+		format = c.Params.Value("format")
+		rsp    lynkapi.DataResults
+	)
+
+	rsp.Kind = "DataResults"
+
+	if format != "csv" && format != "jsonl" {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "invalid format")
+		c.RenderJson(&rsp)
+		return
+	}
+
+	pl := c.host.Assets.Pagelet(name)
+	if pl == nil || pl.Datalet == nil || pl.Datalet.TableName == "" {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_NotFound,
+			fmt.Sprintf("pagelet (%s) not found", name))
+		c.RenderJson(&rsp)
+		return
+	}
+
+	user := sessionUser(c.session)
+	g := c.host.Access.Table(user, pl.Datalet.TableName)
+	if !c.host.Access.Pagelet(user, name) || !g.Read {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied")
+		c.RenderJson(&rsp)
+		return
+	}
+
+	pl, err := dataletUserFilter(pl, user)
+	if err != nil {
+		hlog.Printf("warn", "pagelet %s : %s", name, err.Error())
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied")
+		c.RenderJson(&rsp)
+		return
+	}
+
+	spec := accessSpec(dataletTableSpec(c.host.Layout, pl), g)
+	if spec == nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_NotFound,
+			fmt.Sprintf("table (%s) spec not found", pl.Datalet.TableName))
+		c.RenderJson(&rsp)
+		return
+	}
+
+	fields := spec.Fields
+	if pl.Datalet.List != nil && len(pl.Datalet.List.DisplayFields) > 0 {
+		fields = slices.DeleteFunc(slices.Clone(fields), func(field *lynkapi.FieldSpec) bool {
+			return !slices.Contains(pl.Datalet.List.DisplayFields, field.TagName)
+		})
+	}
+
+	query, err := dataletQuery(c.host.Layout, pl, g, c.Params)
+	if err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		c.RenderJson(&rsp)
+		return
+	}
+	query.Limit = dataletPageSizeMax
+	query.Offset = ""
+
+	// the first page is read before the response starts, so that its
+	// errors are still returned as status
+	rs, err := c.host.Layout.QueryContext(c.dataContext(), query)
+	if err == nil && rs.Status == nil {
+		err = fmt.Errorf("status not found")
+	} else if err == nil && !rs.Status.OK() {
+		err = rs.Status.Err()
+	}
+	if lynkapi.ParseError(err).Code == lynkapi.StatusCode_NotFound {
+		// no rows, the export has the csv head only
+		rs, err = &lynkapi.DataResult{}, nil
+	}
+	if err != nil {
+		rsp.Status = lynkapi.ParseError(err)
+		c.RenderJson(&rsp)
+		return
+	}
+
+	out := c.Response.Out
+	out.Header().Set("Cache-Control", "no-cache")
+	if format == "csv" {
+		out.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		out.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	}
+	out.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s.%s\"", name, format))
+	out.Header().Set("Trailer", dataletExportStatusHeader)
+
+	var (
+		w    = dataletExportWriter(out, format, fields)
+		rows = 0
+	)
+
+	for {
+		c.accessNavRows(rs)
+
+		for _, row := range rs.Rows {
+			if err := w.write(dataRowFields(rs.Spec, row)); err != nil {
+				hlog.Printf("info", "pagelet %s export : %s", name, err.Error())
+				return
+			}
+		}
+		rows += len(rs.Rows)
+
+		if err := w.flush(); err != nil {
+			hlog.Printf("info", "pagelet %s export : %s", name, err.Error())
+			return
+		}
+
+		if rs.NextOffset == "" || rs.NextOffset == query.Offset || len(rs.Rows) == 0 {
+			w.end(nil)
+			return
+		}
+		if rows >= dataletExportRowsMax {
+			hlog.Printf("warn", "pagelet %s export : truncated at %d rows", name, rows)
+			w.end(fmt.Errorf("export truncated at %d rows", rows))
+			return
+		}
+
+		query.Offset = rs.NextOffset
+		rs, err = c.host.Layout.QueryContext(c.dataContext(), query)
+		if err == nil && rs.Status == nil {
+			err = fmt.Errorf("status not found")
+		} else if err == nil && !rs.Status.OK() {
+			err = rs.Status.Err()
+		}
+		if err != nil {
+			// the response is partly sent, it ends with the error marker
+			hlog.Printf("warn", "pagelet %s export : %s", name, err.Error())
+			w.end(fmt.Errorf("export incomplete after %d rows : %s", rows, err.Error()))
+			return
+		}
+	}
+}
+
+type dataletExporter struct {
+	out    io.Writer
+	fields []*lynkapi.FieldSpec
+	csv    *csv.Writer
+}
+
+func dataletExportWriter(out io.Writer, format string, fields []*lynkapi.FieldSpec) *dataletExporter {
+	w := &dataletExporter{
+		out:    out,
+		fields: fields,
+	}
+	if format == "csv" {
+		w.csv = csv.NewWriter(out)
+		head := make([]string, len(fields))
+		for i, field := range fields {
+			head[i] = field.TagName
+		}
+		w.csv.Write(head)
+	}
+	return w
+}
+
+func (it *dataletExporter) write(values map[string]*structpb.Value) error {
+
+	if it.csv != nil {
+		record := make([]string, len(it.fields))
+		for i, field := range it.fields {
+			record[i] = dataletExportCell(field, values[field.TagName])
+		}
+		return it.csv.Write(record)
+	}
+
+	obj := make(map[string]interface{}, len(it.fields))
+	for _, field := range it.fields {
+		obj[field.TagName] = dataletExportJson(field, values[field.TagName])
+	}
+	js, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = it.out.Write(append(js, '\n'))
+	return err
+}
+
+// end finishes the export. An export which did not send all rows ends with
+// a marker of the error, a last CSV record of one cell or a JSON line of
+// dataletExportErrorKey, so that a partial file is not taken as complete.
+func (it *dataletExporter) end(err error) {
+
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+		if it.csv != nil {
+			it.csv.Write([]string{"# " + status})
+		} else if js, err := json.Marshal(map[string]string{
+			dataletExportErrorKey: status,
+		}); err == nil {
+			it.out.Write(append(js, '\n'))
+		}
+		it.flush()
+	}
+
+	if w, ok := it.out.(http.ResponseWriter); ok {
+		w.Header().Set(dataletExportStatusHeader, status)
+	}
+}
+
+func (it *dataletExporter) flush() error {
+	if it.csv != nil {
+		it.csv.Flush()
+		if err := it.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if f, ok := it.out.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// dataletExportJson converts the value by the field type, numbers are sent
+// as float64 by the services.
+func dataletExportJson(field *lynkapi.FieldSpec, v *structpb.Value) interface{} {
+
+	if v == nil {
+		return nil
+	}
+	if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+		return nil
+	}
+
+	switch field.Type {
+	case lynkapi.FieldSpec_Int:
+		if n, ok := v.GetKind().(*structpb.Value_NumberValue); ok {
+			return int64(n.NumberValue)
+		}
+	case lynkapi.FieldSpec_Uint:
+		if n, ok := v.GetKind().(*structpb.Value_NumberValue); ok && n.NumberValue >= 0 {
+			return uint64(n.NumberValue)
+		}
+	}
+
+	return v.AsInterface()
+}
+
+// dataletExportText formats the value as a CSV cell, values of struct or
+// array fields are JSON encoded.
+func dataletExportText(field *lynkapi.FieldSpec, v *structpb.Value) string {
+
+	switch obj := dataletExportJson(field, v).(type) {
+	case nil:
+		return ""
+	case string:
+		return obj
+	case int64:
+		return strconv.FormatInt(obj, 10)
+	case uint64:
+		return strconv.FormatUint(obj, 10)
+	case float64:
+		return strconv.FormatFloat(obj, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(obj)
+	default:
+		js, _ := json.Marshal(obj)
+		return string(js)
+	}
+}
+
+// dataletExportCell returns the CSV cell of the value. A text starting with
+// a character a spreadsheet reads as the start of a formula is prefixed
+// with a quote, so that opening the file does not run it.
+func dataletExportCell(field *lynkapi.FieldSpec, v *structpb.Value) string {
+	s := dataletExportText(field, v)
+	if _, ok := v.GetKind().(*structpb.Value_StringValue); ok && s != "" {
+		switch s[0] {
+		case '=', '+', '-', '@', '\t', '\r':
+			return "'" + s
+		}
+	}
+	return s
+}
+
+// dataRowFields returns the values of the row keyed by the field tag names,
+// a service may return them in Fields or aligned to the spec in Values.
+func dataRowFields(spec *lynkapi.TableSpec, row *lynkapi.DataRow) map[string]*structpb.Value {
+	fields := make(map[string]*structpb.Value, len(row.Fields))
+	for k, v := range row.Fields {
+		fields[k] = v
+	}
+	if spec != nil {
+		for i, field := range spec.Fields {
+			if i < len(row.Values) {
+				fields[field.TagName] = row.Values[i]
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/data"
+)
+
+// filter-bar condition types, set in DataQuery_Filter.Type of a leaf filter.
+// The types other than equal are evaluated by the data layout, not by the
+// remote instances.
+const (
+	filterTypeEqual    = ""
+	filterTypeContains = data.FilterTypeContains
+	filterTypeGte      = data.FilterTypeGte
+	filterTypeLte      = data.FilterTypeLte
+
+	filterTypeAnd = "and"
+)
+
+const (
+	filterItemsMax    = 32
+	filterValueLenMax = 256
+)
+
+// dataletFilterMerge AND-combines the non-empty filters. The AND groups are
+// flattened, the services match one level of inner filters only.
+func dataletFilterMerge(filters ...*lynkapi.DataQuery_Filter) *lynkapi.DataQuery_Filter {
+	var (
+		inner []*lynkapi.DataQuery_Filter
+		group bool
+	)
+	for _, fr := range filters {
+		if fr == nil {
+			continue
+		}
+		if fr.Field == "" && len(fr.Inner) == 0 {
+			continue
+		}
+		if fr.Field != "" && fr.Value == nil {
+			// a field without value only declares the query_filter binding
+			continue
+		}
+		if fr.Field == "" && (fr.Type == filterTypeAnd || fr.Type == "") {
+			inner, group = append(inner, fr.Inner...), true
+			continue
+		}
+		inner = append(inner, fr)
+	}
+	switch len(inner) {
+	case 0:
+		return nil
+	case 1:
+		if !group {
+			return inner[0]
+		}
+	}
+	return &lynkapi.DataQuery_Filter{
+		Type:  filterTypeAnd,
+		Inner: inner,
+	}
+}
+
+// dataletFilterHidden returns the first field of the filter the grant hides,
+// or "" if there is none.
+func dataletFilterHidden(fr *lynkapi.DataQuery_Filter, g *access.TableGrant) string {
+	if fr == nil || len(g.Hidden) == 0 {
+		return ""
+	}
+	if fr.Field != "" && g.Hidden[fr.Field] {
+		return fr.Field
+	}
+	for _, v := range fr.Inner {
+		if field := dataletFilterHidden(v, g); field != "" {
+			return field
+		}
+	}
+	return ""
+}
+
+// dataletFilterParse decodes the base64 encoded filter-bar conditions and
+// checks each of them against the table spec and the condition types the
+// instance of the table evaluates.
+func dataletFilterParse(spec *lynkapi.TableSpec, types []string, s string) ([]*lynkapi.DataQuery_Filter, error) {
+
+	js := base64Decode(s)
+	if js == "" {
+		return nil, fmt.Errorf("invalid query_filters")
+	}
+
+	var items []*lynkapi.DataQuery_Filter
+	if err := jsonDecode([]byte(js), &items); err != nil {
+		return nil, fmt.Errorf("invalid query_filters : %s", err.Error())
+	}
+	if len(items) > filterItemsMax {
+		return nil, fmt.Errorf("too many query_filters")
+	}
+
+	if spec == nil {
+		return nil, fmt.Errorf("table spec not found")
+	}
+
+	var filters []*lynkapi.DataQuery_Filter
+	for _, item := range items {
+		if item == nil || item.Field == "" || len(item.Inner) > 0 {
+			return nil, fmt.Errorf("invalid query_filters item")
+		}
+		field, _ := spec.Field(item.Field)
+		if field == nil {
+			return nil, fmt.Errorf("filter field (%s) not found", item.Field)
+		}
+		if !slices.Contains(types, item.Type) {
+			return nil, fmt.Errorf("filter type (%s) not supported by the table", item.Type)
+		}
+		value, err := dataletFilterValue(field, item.Type, item.Value)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		filters = append(filters, &lynkapi.DataQuery_Filter{
+			Type:  item.Type,
+			Field: field.TagName,
+			Value: value,
+		})
+	}
+	return filters, nil
+}
+
+func dataletFilterValue(field *lynkapi.FieldSpec, typ string, value *structpb.Value) (*structpb.Value, error) {
+
+	if value == nil {
+		return nil, nil
+	}
+
+	switch field.Type {
+
+	case lynkapi.FieldSpec_String:
+		var s string
+		switch value.Kind.(type) {
+		case *structpb.Value_StringValue:
+			s = value.GetStringValue()
+		case *structpb.Value_NumberValue:
+			s = strconv.FormatFloat(value.GetNumberValue(), 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("filter field (%s) invalid value", field.TagName)
+		}
+		if s == "" {
+			return nil, nil
+		}
+		if len(s) > filterValueLenMax {
+			return nil, fmt.Errorf("filter field (%s) value too long", field.TagName)
+		}
+		if len(field.Enums) > 0 {
+			if typ != filterTypeEqual || !slices.Contains(field.Enums, s) {
+				return nil, fmt.Errorf("filter field (%s) invalid enum value", field.TagName)
+			}
+		} else if typ != filterTypeEqual && typ != filterTypeContains {
+			return nil, fmt.Errorf("filter field (%s) invalid type (%s)", field.TagName, typ)
+		}
+		return structpb.NewStringValue(s), nil
+
+	case lynkapi.FieldSpec_Int, lynkapi.FieldSpec_Uint, lynkapi.FieldSpec_Float:
+		if typ != filterTypeEqual && typ != filterTypeGte && typ != filterTypeLte {
+			return nil, fmt.Errorf("filter field (%s) invalid type (%s)", field.TagName, typ)
+		}
+		var (
+			f   float64
+			err error
+		)
+		switch value.Kind.(type) {
+		case *structpb.Value_StringValue:
+			if value.GetStringValue() == "" {
+				return nil, nil
+			}
+			f, err = strconv.ParseFloat(value.GetStringValue(), 64)
+		case *structpb.Value_NumberValue:
+			f = value.GetNumberValue()
+		default:
+			err = fmt.Errorf("invalid value")
+		}
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("filter field (%s) invalid number", field.TagName)
+		}
+		if field.Type != lynkapi.FieldSpec_Float && f != math.Trunc(f) {
+			return nil, fmt.Errorf("filter field (%s) invalid integer", field.TagName)
+		}
+		if field.Type == lynkapi.FieldSpec_Uint && f < 0 {
+			return nil, fmt.Errorf("filter field (%s) invalid unsigned integer", field.TagName)
+		}
+		return structpb.NewNumberValue(f), nil
+
+	case lynkapi.FieldSpec_Bool:
+		if typ != filterTypeEqual {
+			return nil, fmt.Errorf("filter field (%s) invalid type (%s)", field.TagName, typ)
+		}
+		switch value.Kind.(type) {
+		case *structpb.Value_BoolValue:
+			return value, nil
+		case *structpb.Value_StringValue:
+			switch value.GetStringValue() {
+			case "":
+				return nil, nil
+			case "true":
+				return structpb.NewBoolValue(true), nil
+			case "false":
+				return structpb.NewBoolValue(false), nil
+			}
+		}
+		return nil, fmt.Errorf("filter field (%s) invalid bool", field.TagName)
+	}
+
+	return nil, fmt.Errorf("filter field (%s) type (%s) not supported", field.TagName, field.Type)
+}
@@ -0,0 +1,371 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/internal/audit"
+)
+
+const (
+	dataletImportSizeMax   = 8 << 20
+	dataletImportRowsMax   = 10000
+	dataletImportErrorsMax = 1000
+
+	// rows written between the checks of a canceled request
+	dataletImportCancelCheck = 100
+)
+
+var dataletImportNameReplacer = strings.NewReplacer(" ", "", "_", "", "-", "")
+
+type dataletImportRequest struct {
+	// csv or jsonl, the first line of a CSV file is the head of the columns
+	Format string `json:"format"`
+	Data   string `json:"data"`
+	// column name -> field tag name, an empty name skips the column. The
+	// columns not set are mapped to the field of the same name.
+	Mapping map[string]string `json:"mapping,omitempty"`
+	DryRun  bool              `json:"dry_run,omitempty"`
+}
+
+type dataletImportResult struct {
+	Kind    string                   `json:"kind"`
+	Status  *lynkapi.ServiceStatus   `json:"status"`
+	DryRun  bool                     `json:"dry_run,omitempty"`
+	Columns []*dataletImportColumn   `json:"columns,omitempty"`
+	Rows    int                      `json:"rows"`
+	Created int                      `json:"created"`
+	Updated int                      `json:"updated"`
+	Failed  int                      `json:"failed"`
+	Errors  []*dataletImportRowError `json:"errors,omitempty"`
+}
+
+type dataletImportColumn struct {
+	Name  string `json:"name"`
+	Field string `json:"field,omitempty"`
+}
+
+type dataletImportRowError struct {
+	// number of the data row, from 1
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportAction writes the rows of a CSV or JSONL file to the datalet table
+// of a pagelet with data create enabled. Each row passes the checks of
+// UpsertAction, with dry_run set the rows are only checked. The valid rows
+// are written one by one as UpsertAction does, an update reads the row
+// before for the audit trail. The rows that fail are reported by number.
+func (c Datalet) ImportAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	var (
+		req  dataletImportRequest
+		rsp  = dataletImportResult{Kind: "DataImport"}
+		name = c.Params.Value("pagelet")
+	)
+	defer c.RenderJson(&rsp)
+
+	if !authCsrfCheck(c.Controller, c.session) {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid csrf token")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Response.Out, c.Request.Body, dataletImportSizeMax)
+	if err := c.Request.JsonDecode(&req); err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest,
+			fmt.Sprintf("invalid request, the size limit is %d MB", dataletImportSizeMax>>20))
+		return
+	}
+	rsp.DryRun = req.DryRun
+
+	w, status := c.dataletWriteSetup(name)
+	if status != nil {
+		rsp.Status = status
+		return
+	}
+
+	columns, rows, rowErrs, err := dataletImportParse(req.Format, req.Data)
+	if err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		return
+	}
+
+	if rsp.Columns, err = dataletImportMapping(w.spec, columns, req.Mapping); err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		return
+	}
+
+	rsp.Rows = len(rows)
+
+	failed := func(row int, msg string, errs ...*dataletUpsertFieldError) {
+		rsp.Failed++
+		if len(errs) == 0 {
+			errs = []*dataletUpsertFieldError{{Message: msg}}
+		}
+		for _, e := range errs {
+			if len(rsp.Errors) >= dataletImportErrorsMax {
+				break
+			}
+			rsp.Errors = append(rsp.Errors, &dataletImportRowError{
+				Row:     row,
+				Field:   e.Field,
+				Message: e.Message,
+			})
+		}
+	}
+
+	type prepared struct {
+		row int
+		op  string
+		req *lynkapi.DataInsert
+	}
+	var items []*prepared
+
+	for i, values := range rows {
+
+		if msg, ok := rowErrs[i]; ok {
+			failed(i+1, msg)
+			continue
+		}
+
+		ins := &lynkapi.DataInsert{}
+		for _, col := range rsp.Columns {
+			if v, ok := values[col.Name]; ok && col.Field != "" && !dataletUpsertEmpty(v) {
+				ins.Fields = append(ins.Fields, col.Field)
+				ins.Values = append(ins.Values, v)
+			}
+		}
+		if len(ins.Fields) == 0 {
+			failed(i+1, "no values")
+			continue
+		}
+
+		op, status, errs := c.dataletUpsertPrepare(w, ins)
+		if status != nil {
+			failed(i+1, status.Message, errs...)
+			continue
+		}
+
+		items = append(items, &prepared{
+			row: i + 1,
+			op:  op,
+			req: ins,
+		})
+	}
+
+	if req.DryRun {
+		for _, item := range items {
+			if item.op == audit.OpUpdate && c.auditPkRow(w.pl.Datalet.TableName, w.spec,
+				auditFields(item.req.Fields, item.req.Values)) != nil {
+				rsp.Updated++
+			} else {
+				rsp.Created++
+			}
+		}
+		rsp.Status = lynkapi.NewServiceStatusOK()
+		return
+	}
+
+	for i, item := range items {
+
+		if i%dataletImportCancelCheck == 0 {
+			if err := c.Request.Context().Err(); err != nil {
+				for _, v := range items[i:] {
+					failed(v.row, "import canceled")
+				}
+				break
+			}
+		}
+
+		op, rs, err := c.dataletUpsertRun(w, item.req, item.op)
+		if err == nil && rs.Status == nil {
+			err = fmt.Errorf("status not found")
+		} else if err == nil && !rs.Status.OK() {
+			err = rs.Status.Err()
+		}
+		if err != nil {
+			failed(item.row, lynkapi.ParseError(err).Message)
+		} else if op == audit.OpUpdate {
+			rsp.Updated++
+		} else {
+			rsp.Created++
+		}
+	}
+
+	rsp.Status = lynkapi.NewServiceStatusOK()
+}
+
+// dataletImportParse returns the column names, and the rows with the values
+// keyed by the column names. A JSONL line that can not be decoded fails its
+// row only, the message is returned by the row index.
+func dataletImportParse(format, data string) ([]string, []map[string]*structpb.Value, map[int]string, error) {
+
+	var (
+		columns []string
+		rows    []map[string]*structpb.Value
+		rowErrs = map[int]string{}
+	)
+
+	data = strings.TrimPrefix(data, "\ufeff")
+
+	switch format {
+
+	case "csv":
+		r := csv.NewReader(strings.NewReader(data))
+		r.FieldsPerRecord = -1
+		head, err := r.Read()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid csv head : %s", err.Error())
+		}
+		for _, v := range head {
+			columns = append(columns, strings.TrimSpace(v))
+		}
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid csv : %s", err.Error())
+			}
+			if len(rows) >= dataletImportRowsMax {
+				return nil, nil, nil, fmt.Errorf("too many rows, the limit is %d", dataletImportRowsMax)
+			}
+			if len(record) != len(columns) {
+				rowErrs[len(rows)] = fmt.Sprintf("%d values, the head has %d columns",
+					len(record), len(columns))
+				rows = append(rows, nil)
+				continue
+			}
+			row := map[string]*structpb.Value{}
+			for i, v := range record {
+				row[columns[i]] = structpb.NewStringValue(v)
+			}
+			rows = append(rows, row)
+		}
+
+	case "jsonl":
+		seen := map[string]bool{}
+		for _, line := range strings.Split(data, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if len(rows) >= dataletImportRowsMax {
+				return nil, nil, nil, fmt.Errorf("too many rows, the limit is %d", dataletImportRowsMax)
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &obj); err != nil {
+				rowErrs[len(rows)] = "invalid json : " + err.Error()
+				rows = append(rows, nil)
+				continue
+			}
+			row := map[string]*structpb.Value{}
+			for k, v := range obj {
+				sv, err := structpb.NewValue(v)
+				if err != nil {
+					rowErrs[len(rows)] = fmt.Sprintf("column (%s) : %s", k, err.Error())
+					break
+				}
+				row[k] = sv
+			}
+			// new keys of a line are appended to the columns in name order
+			var keys []string
+			for k := range obj {
+				if !seen[k] {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+			rows = append(rows, row)
+		}
+
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid format")
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, nil, fmt.Errorf("no rows found")
+	}
+
+	return columns, rows, rowErrs, nil
+}
+
+// dataletImportMapping maps the columns to the fields of the table. A column
+// not set in the mapping is matched to a free field with the same tag name or
+// name, in any case and without spaces, "_" and "-", otherwise it is skipped.
+func dataletImportMapping(spec *lynkapi.TableSpec, columns []string,
+	mapping map[string]string) ([]*dataletImportColumn, error) {
+
+	var (
+		ls   []*dataletImportColumn
+		used = map[string]string{}
+		norm = func(s string) string {
+			return strings.ToLower(dataletImportNameReplacer.Replace(s))
+		}
+	)
+
+	for _, name := range columns {
+		col := &dataletImportColumn{Name: name}
+		if tag, ok := mapping[name]; ok && tag != "" {
+			if field, _ := spec.Field(tag); field == nil {
+				return nil, fmt.Errorf("column (%s) : field (%s) not found", name, tag)
+			}
+			if prev, ok := used[tag]; ok {
+				return nil, fmt.Errorf("field (%s) mapped by the columns (%s) and (%s)",
+					tag, prev, name)
+			}
+			used[tag] = name
+			col.Field = tag
+		}
+		ls = append(ls, col)
+	}
+
+	for _, col := range ls {
+		if _, ok := mapping[col.Name]; ok {
+			continue
+		}
+		for _, field := range spec.Fields {
+			if _, ok := used[field.TagName]; ok {
+				continue
+			}
+			if norm(field.TagName) == norm(col.Name) ||
+				norm(field.Name) == norm(col.Name) {
+				used[field.TagName] = col.Name
+				col.Field = field.TagName
+				break
+			}
+		}
+	}
+
+	return ls, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hooto/httpsrv"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/data"
+)
+
+// dataletTableSpec returns the table spec of the pagelet datalet. If the
+// pagelet declares its own spec fields, only these and the primary keys
+// are kept.
+func dataletTableSpec(layout *data.LayoutManager, pl *lynkui.Pagelet) *lynkapi.TableSpec {
+
+	spec := layout.TableSpec(pl.Datalet.TableName)
+	if spec == nil || pl.Datalet.TableSpec == nil || len(pl.Datalet.TableSpec.Fields) == 0 {
+		return spec
+	}
+
+	declared := pl.Datalet.TableSpec.Fields
+
+	spec = proto.Clone(spec).(*lynkapi.TableSpec)
+	spec.Fields = slices.DeleteFunc(spec.Fields, func(field *lynkapi.FieldSpec) bool {
+		if field.HasAttr("primary_key") {
+			return false
+		}
+		return !slices.ContainsFunc(declared, func(v *lynkapi.FieldSpec) bool {
+			return (v.TagName != "" && v.TagName == field.TagName) ||
+				(v.Name != "" && v.Name == field.Name)
+		})
+	})
+
+	return spec
+}
+
+// dataletScope returns the field values fixed by the equal conditions of the
+// pagelet filters. The field of a query_filter binding takes the value of the
+// query_filter param, which must be set.
+func dataletScope(pl *lynkui.Pagelet, params *httpsrv.Params) (map[string]*structpb.Value, error) {
+
+	var (
+		fixed = map[string]*structpb.Value{}
+		bound string
+		walk  func(fr *lynkapi.DataQuery_Filter)
+	)
+
+	walk = func(fr *lynkapi.DataQuery_Filter) {
+		if fr == nil {
+			return
+		}
+		if len(fr.Inner) > 0 {
+			if fr.Type == filterTypeAnd || fr.Type == "" {
+				for _, v := range fr.Inner {
+					walk(v)
+				}
+			}
+			return
+		}
+		if fr.Field == "" || fr.Type != filterTypeEqual {
+			return
+		}
+		if fr.Value != nil {
+			fixed[fr.Field] = fr.Value
+		} else {
+			bound = fr.Field
+		}
+	}
+
+	walk(pl.Datalet.Filter)
+	if pl.Datalet.List != nil {
+		walk(pl.Datalet.List.Filter)
+	}
+
+	if bound == "" {
+		return fixed, nil
+	}
+
+	var queryFilter lynkapi.DataQuery_Filter
+	if js := base64Decode(params.Value("query_filter")); js == "" {
+		return nil, fmt.Errorf("query_filter not setup")
+	} else if err := jsonDecode([]byte(js), &queryFilter); err != nil {
+		return nil, fmt.Errorf("invalid query_filter")
+	}
+	if queryFilter.Field != bound || dataletUpsertEmpty(queryFilter.Value) {
+		return nil, fmt.Errorf("query_filter (%s) not setup", bound)
+	}
+	fixed[bound] = queryFilter.Value
+
+	return fixed, nil
+}
+
+// dataletScopeApply sets the fixed values to the request, a request value
+// that differs from the fixed one is rejected.
+func dataletScopeApply(spec *lynkapi.TableSpec, req *lynkapi.DataInsert, fixed map[string]*structpb.Value) []*dataletUpsertFieldError {
+
+	var errs []*dataletUpsertFieldError
+
+	for _, name := range slices.Sorted(maps.Keys(fixed)) {
+
+		value := fixed[name]
+
+		field, _ := spec.Field(name)
+		if field == nil {
+			continue
+		}
+
+		fv, err := dataletUpsertValue(field, value)
+		if err != nil || fv == nil {
+			errs = append(errs, &dataletUpsertFieldError{
+				Field:   name,
+				Message: "invalid value fixed by pagelet",
+			})
+			continue
+		}
+
+		i := slices.Index(req.Fields, name)
+		if i < 0 {
+			req.Fields = append(req.Fields, name)
+			req.Values = append(req.Values, fv)
+			continue
+		}
+
+		if rv, err := dataletUpsertValue(field, req.Values[i]); err != nil ||
+			(rv != nil && !proto.Equal(rv, fv)) {
+			errs = append(errs, &dataletUpsertFieldError{
+				Field:   name,
+				Message: "value is fixed by pagelet",
+			})
+			continue
+		}
+		req.Values[i] = fv
+	}
+
+	return errs
+}
+
+// dataletScopeFilter returns the filter with the fixed values AND-combined.
+func dataletScopeFilter(filter *lynkapi.DataQuery_Filter, fixed map[string]*structpb.Value) *lynkapi.DataQuery_Filter {
+	filters := []*lynkapi.DataQuery_Filter{filter}
+	for _, name := range slices.Sorted(maps.Keys(fixed)) {
+		filters = append(filters, &lynkapi.DataQuery_Filter{
+			Field: name,
+			Value: fixed[name],
+		})
+	}
+	return dataletFilterMerge(filters...)
+}
+
+// dataContext returns the request context with the session user, the data
+// layout resolves the row filters of the virtual tables against it.
+func (c Datalet) dataContext() context.Context {
+	return data.WithUser(c.Request.Context(), sessionUser(c.session))
+}
+
+// dataletUserFilter returns the pagelet with the ${user.*} references of its
+// datalet filters resolved, the pagelet itself is shared and never modified.
+func dataletUserFilter(pl *lynkui.Pagelet, user *lynkui.User) (*lynkui.Pagelet, error) {
+
+	listFilter := pl.Datalet.GetList().GetFilter()
+	if !data.HasUserVar(pl.Datalet.Filter) && !data.HasUserVar(listFilter) {
+		return pl, nil
+	}
+
+	filter, err := data.ResolveFilter(pl.Datalet.Filter, user)
+	if err != nil {
+		return nil, err
+	}
+	if listFilter, err = data.ResolveFilter(listFilter, user); err != nil {
+		return nil, err
+	}
+
+	pl2 := proto.Clone(pl).(*lynkui.Pagelet)
+	pl2.Datalet.Filter = filter
+	if pl2.Datalet.List != nil {
+		pl2.Datalet.List.Filter = listFilter
+	}
+	return pl2, nil
+}
@@ -0,0 +1,636 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hooto/httpsrv"
+
+	"github.com/lynkdb/lynkapi/go/codec"
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+	"github.com/lynkdb/lynkapi/go/oneobject"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/audit"
+	"github.com/lynkdb/lynkui/internal/data"
+	"github.com/lynkdb/lynkui/internal/status"
+)
+
+const testPassword = "secret1"
+
+// testUsers signs in the users by name, all with the test password.
+type testUsers map[string]*lynkui.User
+
+func (it testUsers) Authenticate(name, password string) (*lynkui.User, error) {
+	if u, ok := it[name]; ok && password == testPassword {
+		return u, nil
+	}
+	return nil, nil
+}
+
+type testData struct {
+	host *Host
+	base string
+	dir  string
+}
+
+// testDataHost starts a host with the lynk_dict rows, the pagelets by name
+// and the access policy, which is disabled if empty. The rows are also the
+// ones of the dict table, named apart from the ref table so that the tests
+// see which name a change is recorded with. The audit trail is written to
+// the audit.jsonl file of the test dir.
+func testDataHost(t *testing.T, policy string, pagelets map[string]string,
+	rows ...map[string]interface{}) *testData {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	layout := filepath.Join(dir, "lynkui_layout.json")
+	if err := os.WriteFile(layout, []byte(`{"tables": [
+		{"name": "dict", "ref_instance": "lynkui", "ref_table": "lynk_dict"}
+	]}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	lm := data.NewLayoutManager()
+	if err := lm.Init(layout, true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lm.Close() })
+
+	var do lynkui.MainObjectSet
+	inst, err := oneobject.NewInstance("lynkui", &do)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst.TableSetup("lynk_dict")
+	for _, row := range rows {
+		req := &lynkapi.DataInsert{
+			TableName: "lynk_dict",
+		}
+		for k, v := range row {
+			req.SetField(k, v)
+		}
+		if _, err := inst.Igsert(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lm.RegisterService(inst); err != nil {
+		t.Fatal(err)
+	}
+
+	log := audit.NewLog(filepath.Join(dir, "audit.jsonl"), nil)
+	if err := lm.RegisterService(log); err != nil {
+		t.Fatal(err)
+	}
+
+	am := access.NewManager()
+	if policy != "" {
+		file := filepath.Join(dir, "lynkui_access.json")
+		if err := os.WriteFile(file, []byte(policy), 0640); err != nil {
+			t.Fatal(err)
+		}
+		if err := am.Load(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assets := status.NewSets()
+	for name, body := range pagelets {
+		var pl lynkui.Pagelet
+		if err := codec.Json.Decode([]byte(body), &pl); err != nil {
+			t.Fatal(err)
+		}
+		pl.Name = name
+		assets.SetPagelet(name, &pl)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	lis.Close()
+
+	s := httpsrv.NewService()
+	s.Config.HttpAddr = "127.0.0.1"
+	s.Config.HttpPort = uint16(port)
+
+	// the hosts are registered process wide, each test has its own path
+	h := &Host{
+		Config: &lynkui.ServiceConfig{
+			UrlEntryPath: fmt.Sprintf("/lynkui%d", port),
+			Authenticator: testUsers{
+				"admin": {Name: "admin", Roles: []string{"admin"}},
+				"bob":   {Name: "bob", Roles: []string{"editor"}},
+			},
+		},
+		Layout: lm,
+		Assets: assets,
+		Access: am,
+		Audit:  log,
+	}
+	if err := Setup(s, h); err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(func() { s.Stop() })
+
+	td := &testData{
+		host: h,
+		base: fmt.Sprintf("http://127.0.0.1:%d%s/api/v1", port, h.Config.UrlEntryPath),
+		dir:  dir,
+	}
+	for i := 0; ; i++ {
+		if rsp, err := http.Get(td.base + "/auth/session"); err == nil {
+			rsp.Body.Close()
+			break
+		} else if i >= 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return td
+}
+
+type testClient struct {
+	t    *testing.T
+	base string
+	http *http.Client
+	csrf string
+}
+
+// login returns a client with the session of the user.
+func (it *testData) login(t *testing.T, name string) *testClient {
+	t.Helper()
+
+	jar, _ := cookiejar.New(nil)
+	c := &testClient{
+		t:    t,
+		base: it.base,
+		http: &http.Client{Jar: jar},
+	}
+	var rs authResult
+	c.call("POST", "/auth/login", map[string]string{
+		"name":     name,
+		"password": testPassword,
+	}, &rs)
+	if !rs.Status.OK() {
+		t.Fatalf("login %s : %v", name, rs.Status)
+	}
+	c.csrf = rs.CsrfToken
+	return c
+}
+
+// call sends the request with the body encoded as JSON, and decodes the
+// response to v.
+func (it *testClient) call(method, path string, body, v interface{}) {
+	it.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			it.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, it.base+path, &buf)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(authCsrfHeader, it.csrf)
+
+	rsp, err := it.http.Do(req)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	if err := json.NewDecoder(rsp.Body).Decode(v); err != nil {
+		it.t.Fatalf("%s %s : %s", method, path, err.Error())
+	}
+}
+
+// upsert writes the fields of the row through the pagelet.
+func (it *testClient) upsert(pagelet string, row map[string]interface{}, version string) *dataletUpsertResult {
+	it.t.Helper()
+
+	req := map[string]interface{}{
+		"fields":  []string{},
+		"values":  []interface{}{},
+		"version": version,
+	}
+	for k, v := range row {
+		req["fields"] = append(req["fields"].([]string), k)
+		req["values"] = append(req["values"].([]interface{}), v)
+	}
+	var rs dataletUpsertResult
+	it.call("POST", "/datalet/upsert?pagelet="+pagelet, req, &rs)
+	return &rs
+}
+
+// delete deletes the rows of the filter through the pagelet.
+func (it *testClient) delete(pagelet string, filter map[string]interface{}) *lynkapi.DataResult {
+	it.t.Helper()
+
+	var rs lynkapi.DataResult
+	it.call("POST", "/datalet/delete?pagelet="+pagelet, map[string]interface{}{
+		"filter": filter,
+	}, &rs)
+	return &rs
+}
+
+// dictIds returns the ids of the lynk_dict rows.
+func (it *testData) dictIds(t *testing.T) map[string]bool {
+	t.Helper()
+
+	ids := map[string]bool{}
+	rs, err := it.host.Layout.Query(&lynkapi.DataQuery{
+		TableName: "lynk_dict",
+		Limit:     100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rs.Rows {
+		ids[row.Fields["id"].GetStringValue()] = true
+	}
+	return ids
+}
+
+// auditEntries returns the entries of the audit trail in the order written.
+func (it *testData) auditEntries(t *testing.T) []*audit.Entry {
+	t.Helper()
+
+	fp, err := os.Open(filepath.Join(it.dir, "audit.jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	var (
+		entries []*audit.Entry
+		sc      = bufio.NewScanner(fp)
+	)
+	for sc.Scan() {
+		var e audit.Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries
+}
+
+const testDictPagelets = `{
+	"datalet": {
+		"table_name": "dict",
+		"filter": {"field": "ns", "value": "a"}
+	},
+	"exp_data_create_enable": true,
+	"exp_data_update_enable": true,
+	"exp_data_delete_enable": true
+}`
+
+func testDictRows() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": "a1", "ns": "a", "name": "one", "display_name": "x"},
+		{"id": "a2", "ns": "a", "name": "two", "display_name": "x"},
+		{"id": "b1", "ns": "b", "name": "three", "display_name": "x"},
+	}
+}
+
+func TestDataletDeleteScope(t *testing.T) {
+
+	td := testDataHost(t, "", map[string]string{"dicta": testDictPagelets}, testDictRows()...)
+	c := td.login(t, "admin")
+
+	if rs := c.delete("dicta", map[string]interface{}{
+		"field": "id", "value": "b1",
+	}); rs.Status.Code != lynkapi.StatusCode_NotFound {
+		t.Fatalf("delete out of the pagelet scope, want not found, got %v", rs.Status)
+	}
+	if ids := td.dictIds(t); len(ids) != 3 {
+		t.Fatalf("rows after a rejected delete : %v", ids)
+	}
+
+	rs := c.delete("dicta", map[string]interface{}{
+		"field": "display_name", "value": "x",
+	})
+	if !rs.Status.OK() {
+		t.Fatalf("delete in the pagelet scope : %v", rs.Status)
+	}
+	if ids := td.dictIds(t); len(ids) != 1 || !ids["b1"] {
+		t.Fatalf("rows after the delete : %v", ids)
+	}
+}
+
+func TestDataletAuditEntries(t *testing.T) {
+
+	td := testDataHost(t, "", map[string]string{"dicta": testDictPagelets}, testDictRows()...)
+	c := td.login(t, "admin")
+
+	if rs := c.upsert("dicta", map[string]interface{}{
+		"id": "a1", "display_name": "y",
+	}, ""); !rs.Status.OK() {
+		t.Fatalf("update : %v", rs.Status)
+	}
+	if rs := c.delete("dicta", map[string]interface{}{
+		"field": "display_name", "value": "x",
+	}); !rs.Status.OK() {
+		t.Fatalf("delete : %v", rs.Status)
+	}
+
+	entries := td.auditEntries(t)
+	if len(entries) != 2 {
+		t.Fatalf("audit entries %d, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.TableName != "dict" || e.Pagelet != "dicta" || e.User != "admin" {
+			t.Errorf("audit entry %s of %s/%s by %s", e.Op, e.Pagelet, e.TableName, e.User)
+		}
+	}
+
+	if e := entries[0]; e.Op != audit.OpUpdate || e.Pk != "id=a1" ||
+		!bytes.Contains([]byte(e.Before), []byte(`"display_name":"x"`)) ||
+		!bytes.Contains([]byte(e.After), []byte(`"display_name":"y"`)) {
+		t.Errorf("update entry : %+v", e)
+	}
+
+	// the row deleted only, not the rows of the filter out of the scope
+	if e := entries[1]; e.Op != audit.OpDelete || e.Pk != "id=a2" ||
+		!bytes.Contains([]byte(e.Before), []byte(`"ns":"a"`)) {
+		t.Errorf("delete entry : %+v", e)
+	}
+}
+
+const testEditorPolicy = `{"roles": [
+	{"name": "admin", "admin": true, "pagelets": ["*"], "tables": [{"name": "*", "write": true}]},
+	{"name": "editor", "pagelets": ["dicta"], "tables": [
+		{"name": "dict", "write": true, "hidden_fields": ["description"]}
+	]}
+]}`
+
+func TestDataletDeleteHiddenFilter(t *testing.T) {
+
+	rows := testDictRows()
+	rows[0]["description"] = "secret"
+
+	td := testDataHost(t, testEditorPolicy, map[string]string{"dicta": testDictPagelets}, rows...)
+	c := td.login(t, "bob")
+
+	for _, fr := range []map[string]interface{}{
+		{"field": "description", "value": "secret"},
+		{"type": "and", "inner": []interface{}{
+			map[string]interface{}{"field": "id", "value": "a1"},
+			map[string]interface{}{"field": "description", "value": "secret"},
+		}},
+	} {
+		if rs := c.delete("dicta", fr); rs.Status.Code != lynkapi.StatusCode_BadRequest {
+			t.Fatalf("delete by a hidden field, want bad request, got %v", rs.Status)
+		}
+	}
+	if ids := td.dictIds(t); len(ids) != 3 {
+		t.Fatalf("rows after the rejected deletes : %v", ids)
+	}
+
+	if rs := c.delete("dicta", map[string]interface{}{
+		"field": "id", "value": "a1",
+	}); !rs.Status.OK() {
+		t.Fatalf("delete by a visible field : %v", rs.Status)
+	}
+}
+
+// rowVersions returns the row versions of the datalet run of the pagelet.
+func (it *testClient) rowVersions(pagelet string) map[string]string {
+	it.t.Helper()
+
+	var rs struct {
+		Status  *lynkapi.ServiceStatus `json:"status"`
+		Results []struct {
+			RowVersions map[string]string `json:"row_versions"`
+		} `json:"results"`
+	}
+	it.call("GET", "/datalet/run?pagelet="+pagelet, nil, &rs)
+	if len(rs.Results) != 1 {
+		it.t.Fatalf("run %s : %v", pagelet, rs.Status)
+	}
+	return rs.Results[0].RowVersions
+}
+
+func TestDataletUpsertVersion(t *testing.T) {
+
+	rows := testDictRows()
+	rows[0]["description"] = "secret"
+
+	td := testDataHost(t, testEditorPolicy, map[string]string{"dicta": testDictPagelets}, rows...)
+	var (
+		admin = td.login(t, "admin")
+		bob   = td.login(t, "bob")
+	)
+
+	version := bob.rowVersions("dicta")["a1"]
+	if version == "" {
+		t.Fatal("row version of a1 not found")
+	}
+
+	rs := bob.upsert("dicta", map[string]interface{}{
+		"id": "a1", "display_name": "y",
+	}, version)
+	if !rs.Status.OK() || rs.Version == "" || rs.Version == version {
+		t.Fatalf("update with the current version : %v, version %q", rs.Status, rs.Version)
+	}
+	if v := bob.rowVersions("dicta")["a1"]; v != rs.Version {
+		t.Fatalf("version after the update %q, the run has %q", rs.Version, v)
+	}
+	version = rs.Version
+
+	// a change of a field hidden from bob is a conflict too, the current
+	// values sent back do not have the hidden fields
+	if rs := admin.upsert("dicta", map[string]interface{}{
+		"id": "a1", "description": "other",
+	}, ""); !rs.Status.OK() {
+		t.Fatalf("update by admin : %v", rs.Status)
+	}
+	rs = bob.upsert("dicta", map[string]interface{}{
+		"id": "a1", "display_name": "z",
+	}, version)
+	if rs.Status.Code != lynkapi.StatusCode_Conflict {
+		t.Fatalf("update with a stale version, want conflict, got %v", rs.Status)
+	}
+	if rs.Current == nil || rs.Current["display_name"].GetStringValue() != "y" {
+		t.Fatalf("current row of the conflict : %v", rs.Current)
+	}
+	if _, ok := rs.Current["description"]; ok {
+		t.Fatal("current row of the conflict has a hidden field")
+	}
+	if rs.Version == "" || rs.Version == version {
+		t.Fatalf("current version of the conflict %q", rs.Version)
+	}
+
+	// the version is keyed, it does not confirm a guess of the hidden values
+	sum := sha256.Sum256([]byte(`{"description":"other","display_name":"y","id":"a1","name":"one","ns":"a"}`))
+	if rs.Version == hex.EncodeToString(sum[:8]) {
+		t.Fatal("row version is an unkeyed hash of the values")
+	}
+
+	if rs := bob.upsert("dicta", map[string]interface{}{
+		"id": "nonexist", "display_name": "z",
+	}, version); rs.Status.Code != lynkapi.StatusCode_Conflict {
+		t.Fatalf("update of a deleted row, want conflict, got %v", rs.Status)
+	}
+}
+
+// get returns the body of the response.
+func (it *testClient) get(path string) string {
+	it.t.Helper()
+
+	rsp, err := it.http.Get(it.base + path)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestDataletExportCsvFormula(t *testing.T) {
+
+	td := testDataHost(t, "", map[string]string{"dicta": testDictPagelets},
+		map[string]interface{}{"id": "a1", "ns": "a", "name": "n1", "display_name": "=1+2"},
+		map[string]interface{}{"id": "a2", "ns": "a", "name": "n2", "display_name": "+cmd"},
+		map[string]interface{}{"id": "a3", "ns": "a", "name": "n3", "display_name": "-2"},
+		map[string]interface{}{"id": "a4", "ns": "a", "name": "n4", "display_name": "@sum"},
+		map[string]interface{}{"id": "a5", "ns": "a", "name": "n5", "display_name": "\t=1"},
+		map[string]interface{}{"id": "a6", "ns": "a", "name": "n6", "display_name": "\r=1"},
+		map[string]interface{}{"id": "a7", "ns": "a", "name": "n7", "display_name": "a=b"},
+	)
+	c := td.login(t, "admin")
+
+	records, err := csv.NewReader(strings.NewReader(
+		c.get("/datalet/export?pagelet=dicta&format=csv"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 8 {
+		t.Fatalf("csv records %d, want 8", len(records))
+	}
+
+	col := slices.Index(records[0], "display_name")
+	if col < 0 {
+		t.Fatalf("csv head %v", records[0])
+	}
+	want := map[string]string{
+		"a1": "'=1+2",
+		"a2": "'+cmd",
+		"a3": "'-2",
+		"a4": "'@sum",
+		"a5": "'\t=1",
+		"a6": "'\r=1",
+		"a7": "a=b",
+	}
+	id := slices.Index(records[0], "id")
+	for _, r := range records[1:] {
+		if w := want[r[id]]; r[col] != w {
+			t.Errorf("row %s display_name %q, want %q", r[id], r[col], w)
+		}
+	}
+
+	// the JSON lines are sent as is
+	if body := c.get("/datalet/export?pagelet=dicta&format=jsonl"); !strings.Contains(body, `"display_name":"=1+2"`) {
+		t.Errorf("jsonl export : %s", body)
+	}
+}
+
+func TestDataletImport(t *testing.T) {
+
+	td := testDataHost(t, "", map[string]string{"dicta": testDictPagelets}, testDictRows()...)
+	c := td.login(t, "admin")
+
+	csvData := "id,ns,name,display_name\n" +
+		"a1,a,one,updated\n" +
+		",a,four,created\n" +
+		"b1,b,three,out of scope\n"
+
+	for _, dryRun := range []bool{true, false} {
+		var rs dataletImportResult
+		c.call("POST", "/datalet/import?pagelet=dicta", map[string]interface{}{
+			"format":  "csv",
+			"data":    csvData,
+			"dry_run": dryRun,
+		}, &rs)
+		if !rs.Status.OK() || rs.DryRun != dryRun {
+			t.Fatalf("import, dry run %v : %v", dryRun, rs.Status)
+		}
+		if rs.Rows != 3 || rs.Updated != 1 || rs.Created != 1 || rs.Failed != 1 {
+			t.Fatalf("import, dry run %v : rows %d, updated %d, created %d, failed %d",
+				dryRun, rs.Rows, rs.Updated, rs.Created, rs.Failed)
+		}
+		if len(rs.Errors) == 0 || rs.Errors[0].Row != 3 {
+			t.Fatalf("import, dry run %v : errors %v", dryRun, rs.Errors)
+		}
+
+		if dryRun {
+			if ids := td.dictIds(t); len(ids) != 3 {
+				t.Fatalf("rows after the dry run : %v", ids)
+			}
+			if entries := td.auditEntries(t); len(entries) != 0 {
+				t.Fatalf("audit entries of the dry run : %d", len(entries))
+			}
+		}
+	}
+
+	if ids := td.dictIds(t); len(ids) != 4 {
+		t.Fatalf("rows after the import : %v", ids)
+	}
+
+	entries := td.auditEntries(t)
+	if len(entries) != 2 {
+		t.Fatalf("audit entries of the import %d, want 2", len(entries))
+	}
+	ops := map[string]int{}
+	for _, e := range entries {
+		if e.TableName != "dict" || e.Pagelet != "dicta" {
+			t.Errorf("audit entry %s of %s/%s", e.Op, e.Pagelet, e.TableName)
+		}
+		ops[e.Op]++
+	}
+	if ops[audit.OpUpdate] != 1 || ops[audit.OpCreate] != 1 {
+		t.Errorf("audit entry ops %v", ops)
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/audit"
+)
+
+const (
+	upsertStringLenDef = 4096
+	upsertTextLenDef   = 1 << 20
+	upsertFieldsMax    = 256
+)
+
+type dataletUpsertRequest struct {
+	*lynkapi.DataInsert
+	// the row version of the datalet result the update is based on, if set
+	// the update fails with a conflict if the row was changed since
+	Version string `json:"version,omitempty"`
+}
+
+type dataletUpsertResult struct {
+	Kind   string                     `json:"kind"`
+	Status *lynkapi.ServiceStatus     `json:"status,omitempty"`
+	Spec   *lynkapi.TableSpec         `json:"spec,omitempty"`
+	Rows   []*lynkapi.DataRow         `json:"rows,omitempty"`
+	Errors []*dataletUpsertFieldError `json:"errors,omitempty"`
+	// the version of the row after an update, or the version and the
+	// values of the row in the table on a conflict
+	Version string                     `json:"version,omitempty"`
+	Current map[string]*structpb.Value `json:"current,omitempty"`
+}
+
+type dataletUpsertFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// dataletUpsertCheck validates the fields of the request against the table
+// spec, and replaces the values with the coerced ones. A request carrying all
+// primary keys is checked as an update, otherwise as a create.
+func dataletUpsertCheck(spec *lynkapi.TableSpec, req *lynkapi.DataInsert) ([]*dataletUpsertFieldError, error) {
+
+	if len(req.Fields) != len(req.Values) {
+		return nil, fmt.Errorf("fields and values mismatch")
+	}
+	if len(req.Fields) == 0 {
+		return nil, fmt.Errorf("fields not setup")
+	}
+	if len(req.Fields) > upsertFieldsMax {
+		return nil, fmt.Errorf("too many fields")
+	}
+
+	var (
+		errs   []*dataletUpsertFieldError
+		values = map[string]*structpb.Value{}
+		update = dataletUpsertIsUpdate(spec, req)
+	)
+
+	errorf := func(field, format string, args ...interface{}) {
+		errs = append(errs, &dataletUpsertFieldError{
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, name := range req.Fields {
+		if _, ok := values[name]; ok {
+			errorf(name, "field set more than once")
+			continue
+		}
+		values[name] = req.Values[i]
+	}
+
+	var (
+		fields []string
+		items  []*structpb.Value
+	)
+
+	for _, name := range req.Fields {
+
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		delete(values, name)
+
+		field, _ := spec.Field(name)
+		if field == nil {
+			errorf(name, "field not found")
+			continue
+		}
+
+		if !update && dataletFieldGenerated(field) {
+			errorf(name, "field is generated by server")
+			continue
+		}
+
+		v, err := dataletUpsertValue(field, value)
+		if err != nil {
+			errorf(name, "%s", err.Error())
+			continue
+		}
+		if v == nil {
+			continue
+		}
+
+		fields = append(fields, name)
+		items = append(items, v)
+	}
+
+	for _, field := range spec.Fields {
+		if (!update && field.HasAttr("create_required")) ||
+			(update && field.HasAttr("update_required")) {
+			if !slices.Contains(fields, field.TagName) &&
+				!slices.ContainsFunc(errs, func(e *dataletUpsertFieldError) bool {
+					return e.Field == field.TagName
+				}) {
+				errorf(field.TagName, "field is required")
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	req.Fields, req.Values = fields, items
+
+	return nil, nil
+}
+
+// dataletFieldGenerated reports whether the field value is generated by the
+// data service on create.
+func dataletFieldGenerated(field *lynkapi.FieldSpec) bool {
+	return field.HasAttr("rand_hex") || field.HasAttr("object_id") ||
+		field.FuncAttr("rand_hex", "object_id") != nil
+}
+
+// dataletUpsertReadOnly rejects the request fields the user may not write.
+// The primary keys of an update only identify the row, and the values fixed
+// by the pagelet are set by the server, both are accepted.
+func dataletUpsertReadOnly(spec *lynkapi.TableSpec, req *lynkapi.DataInsert, g *access.TableGrant,
+	fixed map[string]*structpb.Value, update bool) []*dataletUpsertFieldError {
+	var errs []*dataletUpsertFieldError
+	for _, name := range req.Fields {
+		if _, ok := fixed[name]; ok || !g.ReadOnly[name] {
+			continue
+		}
+		if field, _ := spec.Field(name); update && field != nil && field.HasAttr("primary_key") {
+			continue
+		}
+		errs = append(errs, &dataletUpsertFieldError{
+			Field:   name,
+			Message: "field is read-only",
+		})
+	}
+	return errs
+}
+
+// dataletUpsertIsUpdate reports whether the request carries all primary keys
+// of the table.
+func dataletUpsertIsUpdate(spec *lynkapi.TableSpec, req *lynkapi.DataInsert) bool {
+	n := 0
+	for _, field := range spec.Fields {
+		if !field.HasAttr("primary_key") {
+			continue
+		}
+		n++
+		i := slices.Index(req.Fields, field.TagName)
+		if i < 0 || i >= len(req.Values) || dataletUpsertEmpty(req.Values[i]) {
+			return false
+		}
+	}
+	return n > 0
+}
+
+func dataletUpsertEmpty(v *structpb.Value) bool {
+	if v == nil {
+		return true
+	}
+	switch v.Kind.(type) {
+	case nil, *structpb.Value_NullValue:
+		return true
+	case *structpb.Value_StringValue:
+		return v.GetStringValue() == ""
+	}
+	return false
+}
+
+// dataletUpsertValue returns the value converted to the field type, or nil
+// if an optional value is left empty.
+func dataletUpsertValue(field *lynkapi.FieldSpec, value *structpb.Value) (*structpb.Value, error) {
+
+	if value == nil {
+		value = structpb.NewNullValue()
+	}
+
+	required := field.HasAttr("create_required") || field.HasAttr("update_required")
+
+	switch field.Type {
+
+	case lynkapi.FieldSpec_String:
+		var s string
+		switch value.Kind.(type) {
+		case nil, *structpb.Value_NullValue:
+		case *structpb.Value_StringValue:
+			s = value.GetStringValue()
+		case *structpb.Value_NumberValue:
+			s = strconv.FormatFloat(value.GetNumberValue(), 'f', -1, 64)
+		case *structpb.Value_BoolValue:
+			s = strconv.FormatBool(value.GetBoolValue())
+		default:
+			return nil, fmt.Errorf("invalid string value")
+		}
+		if s == "" {
+			if required {
+				return nil, fmt.Errorf("field is required")
+			}
+			if len(field.Enums) > 0 {
+				return nil, nil
+			}
+			return structpb.NewStringValue(s), nil
+		}
+		if len(field.Enums) > 0 && !slices.Contains(field.Enums, s) {
+			return nil, fmt.Errorf("value must be one of the enums")
+		}
+		if n := dataletUpsertLenMax(field); len(s) > n {
+			return nil, fmt.Errorf("value too long, max length %d", n)
+		}
+		return structpb.NewStringValue(s), nil
+
+	case lynkapi.FieldSpec_Int, lynkapi.FieldSpec_Uint, lynkapi.FieldSpec_Float:
+		var (
+			f   float64
+			err error
+		)
+		switch value.Kind.(type) {
+		case nil, *structpb.Value_NullValue:
+			if required {
+				return nil, fmt.Errorf("field is required")
+			}
+			return nil, nil
+		case *structpb.Value_StringValue:
+			if value.GetStringValue() == "" {
+				if required {
+					return nil, fmt.Errorf("field is required")
+				}
+				return nil, nil
+			}
+			f, err = strconv.ParseFloat(value.GetStringValue(), 64)
+		case *structpb.Value_NumberValue:
+			f = value.GetNumberValue()
+		default:
+			err = fmt.Errorf("invalid value")
+		}
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid number")
+		}
+		if field.Type != lynkapi.FieldSpec_Float && f != math.Trunc(f) {
+			return nil, fmt.Errorf("invalid integer")
+		}
+		if field.Type == lynkapi.FieldSpec_Uint && f < 0 {
+			return nil, fmt.Errorf("invalid unsigned integer")
+		}
+		if v, ok := field.Opts["min_value"]; ok && f < v.GetNumberValue() {
+			return nil, fmt.Errorf("value must be >= %v", v.GetNumberValue())
+		}
+		if v, ok := field.Opts["max_value"]; ok && f > v.GetNumberValue() {
+			return nil, fmt.Errorf("value must be <= %v", v.GetNumberValue())
+		}
+		return structpb.NewNumberValue(f), nil
+
+	case lynkapi.FieldSpec_Bool:
+		switch value.Kind.(type) {
+		case *structpb.Value_BoolValue:
+			return value, nil
+		case nil, *structpb.Value_NullValue:
+			if required {
+				return nil, fmt.Errorf("field is required")
+			}
+			return nil, nil
+		case *structpb.Value_StringValue:
+			switch value.GetStringValue() {
+			case "":
+				if required {
+					return nil, fmt.Errorf("field is required")
+				}
+				return nil, nil
+			case "true":
+				return structpb.NewBoolValue(true), nil
+			case "false":
+				return structpb.NewBoolValue(false), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid bool value")
+
+	case lynkapi.FieldSpec_Struct:
+		switch value.Kind.(type) {
+		case *structpb.Value_StructValue, *structpb.Value_ListValue:
+			return value, nil
+		case nil, *structpb.Value_NullValue:
+			if required {
+				return nil, fmt.Errorf("field is required")
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid struct value")
+	}
+
+	return nil, fmt.Errorf("type (%s) not supported", field.Type)
+}
+
+func dataletUpsertLenMax(field *lynkapi.FieldSpec) int {
+	if v, ok := field.Opts["max_len"]; ok && v.GetNumberValue() > 0 {
+		return int(v.GetNumberValue())
+	}
+	if field.HasAttr("string_text") {
+		return upsertTextLenDef
+	}
+	return upsertStringLenDef
+}
+
+// dataletWrite is the pagelet side of the data changes of a request, it is
+// resolved once and shared by the rows of an import.
+type dataletWrite struct {
+	name  string
+	pl    *lynkui.Pagelet
+	spec  *lynkapi.TableSpec
+	grant *access.TableGrant
+	fixed map[string]*structpb.Value
+}
+
+func (c Datalet) dataletWriteSetup(name string) (*dataletWrite, *lynkapi.ServiceStatus) {
+
+	pl, g, rc := dataletWritable(c.host, sessionUser(c.session), name, "")
+	if rc != "" {
+		return nil, lynkapi.NewServiceStatus(rc,
+			fmt.Sprintf("pagelet (%s) does not allow data changes", name))
+	}
+
+	spec := dataletTableSpec(c.host.Layout, pl)
+	if spec == nil {
+		return nil, lynkapi.NewServiceStatus(lynkapi.StatusCode_NotFound,
+			fmt.Sprintf("table (%s) spec not found", pl.Datalet.TableName))
+	}
+
+	fixed, err := dataletScope(pl, c.Params)
+	if err != nil {
+		return nil, lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+	}
+
+	return &dataletWrite{
+		name:  name,
+		pl:    pl,
+		spec:  spec,
+		grant: g,
+		fixed: fixed,
+	}, nil
+}
+
+// dataletUpsertPrepare runs the checks of one row and coerces its values,
+// it returns the op of the row, create or update.
+func (c Datalet) dataletUpsertPrepare(w *dataletWrite, req *lynkapi.DataInsert) (string,
+	*lynkapi.ServiceStatus, []*dataletUpsertFieldError) {
+
+	req.InstanceName, req.TableName = "", w.pl.Datalet.TableName
+
+	if errs := dataletScopeApply(w.spec, req, w.fixed); len(errs) > 0 {
+		return "", lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "invalid fields"), errs
+	}
+
+	op := audit.OpCreate
+	if dataletUpsertIsUpdate(w.spec, req) {
+		op = audit.OpUpdate
+	}
+	if _, _, rc := dataletWritable(c.host, sessionUser(c.session), w.name, op); rc != "" {
+		return "", lynkapi.NewServiceStatus(rc,
+			fmt.Sprintf("pagelet (%s) does not allow data %s", w.name, op)), nil
+	}
+
+	if errs := dataletUpsertReadOnly(w.spec, req, w.grant, w.fixed, op == audit.OpUpdate); len(errs) > 0 {
+		return "", lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid fields"), errs
+	}
+
+	if errs, err := dataletUpsertCheck(w.spec, req); err != nil {
+		return "", lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error()), nil
+	} else if len(errs) > 0 {
+		return "", lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "invalid fields"), errs
+	}
+
+	return op, nil, nil
+}
+
+// dataletUpsertRun writes the prepared row and records it in the audit
+// trail. The returned op is create if an update did not hit a row.
+func (c Datalet) dataletUpsertRun(w *dataletWrite, req *lynkapi.DataInsert, op string) (string,
+	*lynkapi.DataResult, error) {
+
+	var before map[string]*structpb.Value
+	if op == audit.OpUpdate {
+		before = c.auditPkRow(w.pl.Datalet.TableName, w.spec, auditFields(req.Fields, req.Values))
+	}
+
+	rs, err := c.host.Layout.UpsertContext(c.dataContext(), req)
+	if err == nil && (rs.Status == nil || !rs.Status.OK()) {
+		return op, rs, nil
+	}
+	if err != nil {
+		return op, nil, err
+	}
+
+	after := map[string]*structpb.Value{}
+	for k, v := range before {
+		after[k] = v
+	}
+	for k, v := range auditFields(req.Fields, req.Values) {
+		after[k] = v
+	}
+	if before == nil {
+		op = audit.OpCreate
+	}
+	c.auditRecord(&audit.Entry{
+		Pagelet:   w.name,
+		TableName: w.pl.Datalet.TableName,
+		Op:        op,
+		Pk:        auditPk(w.spec, after),
+		Before:    auditValues(before),
+		After:     auditValues(after),
+	})
+
+	return op, rs, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+)
+
+// dataletResult is a datalet query result with the versions of its rows. A
+// client sends the version of a row back with an update, which fails with a
+// conflict if the row was changed since it was read.
+type dataletResult struct {
+	*lynkapi.DataResult
+	// the number of the matching rows, if the instance counts them
+	Total int64 `json:"total,omitempty"`
+	// the filter-bar condition types the table supports
+	FilterTypes []string `json:"filter_types,omitempty"`
+	// row id -> row version
+	RowVersions map[string]string `json:"row_versions,omitempty"`
+}
+
+type dataletResults struct {
+	Kind    string                 `json:"kind"`
+	Status  *lynkapi.ServiceStatus `json:"status,omitempty"`
+	Results []*dataletResult       `json:"results,omitempty"`
+}
+
+// the key of the row versions, the versions read before a restart of the
+// server conflict with the ones after it
+var dataRowVersionKey = func() []byte {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}()
+
+// dataRowVersion returns the content hash of a row, of all the fields of the
+// table spec, including the fields hidden from the user. It is keyed by a
+// secret of the server, so that a user cannot confirm a guess of the hidden
+// values against it.
+func dataRowVersion(fields map[string]*structpb.Value) string {
+	values := map[string]interface{}{}
+	for k, v := range fields {
+		values[k] = v.AsInterface()
+	}
+	js, _ := json.Marshal(values)
+	mac := hmac.New(sha256.New, dataRowVersionKey)
+	mac.Write(js)
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func dataRowVersions(rs *lynkapi.DataResult) map[string]string {
+	if rs.Spec == nil || len(rs.Rows) == 0 {
+		return nil
+	}
+	versions := map[string]string{}
+	for _, row := range rs.Rows {
+		if row.Id != "" {
+			versions[row.Id] = dataRowVersion(dataRowFields(rs.Spec, row))
+		}
+	}
+	return versions
+}
+
+// dataletVersionCheck compares the version sent with an update with the row
+// in the table. On a conflict it returns the current values of the row,
+// without the fields hidden from the user, and its version. The check and
+// the write are not atomic, it catches the edits of rows read long before
+// they are committed.
+func (c Datalet) dataletVersionCheck(w *dataletWrite, req *lynkapi.DataInsert,
+	version string) (*lynkapi.ServiceStatus, map[string]*structpb.Value, string) {
+
+	row := c.auditPkRow(w.pl.Datalet.TableName, w.spec, auditFields(req.Fields, req.Values))
+	if row == nil {
+		return lynkapi.NewServiceStatus(lynkapi.StatusCode_Conflict,
+			"the row was deleted by another user"), nil, ""
+	}
+
+	current := dataRowVersion(row)
+	if current == version {
+		return nil, nil, current
+	}
+
+	fields := map[string]*structpb.Value{}
+	for k, v := range row {
+		if !w.grant.Hidden[k] {
+			fields[k] = v
+		}
+	}
+
+	return lynkapi.NewServiceStatus(lynkapi.StatusCode_Conflict,
+		"the row was changed by another user"), fields, current
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"net/http"
+
+	"github.com/hooto/httpsrv"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+type Project struct {
+	*httpsrv.Controller
+	host    *Host
+	session *authSession
+}
+
+// Init admits the admins only, the project actions expose the layout and
+// the files of the project.
+func (c *Project) Init() int {
+	var rc int
+	if c.host, rc = hostInit(c.Controller); rc == 0 {
+		c.session, rc = authInit(c.Controller, c.host)
+	}
+	if rc == 0 && !c.host.Access.Admin(sessionUser(c.session)) {
+		// the response buffer is not flushed if Init fails, write it out directly
+		c.Response.Out.Header().Set("Content-Type", "application/json")
+		c.Response.Out.WriteHeader(http.StatusForbidden)
+		c.Response.Out.Write(jsonEncode(&projectErrorResult{
+			Kind:   "Project",
+			Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "access denied"),
+		}))
+		return 1
+	}
+	return rc
+}
+
+type projectErrorResult struct {
+	Kind   string                 `json:"kind"`
+	Status *lynkapi.ServiceStatus `json:"status"`
+}
+
+type projectValidateResult struct {
+	Kind   string                  `json:"kind"`
+	Errors []*lynkui.ValidateError `json:"errors"`
+}
+
+type projectLayoutChangesResult struct {
+	Kind  string                 `json:"kind"`
+	Items []*lynkui.LayoutChange `json:"items"`
+}
+
+type projectHealthResult struct {
+	Kind  string                   `json:"kind"`
+	Items []*lynkui.InstanceHealth `json:"items"`
+}
+
+type projectSpecInvalidateResult struct {
+	Kind     string                 `json:"kind"`
+	Status   *lynkapi.ServiceStatus `json:"status,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+}
+
+// ValidateAction reports the errors of the project files which failed to
+// load, the pagelets of these files keep their last-good version.
+func (c Project) ValidateAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	c.RenderJson(&projectValidateResult{
+		Kind:   "ProjectValidate",
+		Errors: c.host.Assets.Errors(),
+	})
+}
+
+// LayoutChangesAction lists the recent live reloads of the data layout.
+func (c Project) LayoutChangesAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	c.RenderJson(&projectLayoutChangesResult{
+		Kind:  "LayoutChanges",
+		Items: c.host.Layout.Changes(),
+	})
+}
+
+// HealthAction lists the connection state of the remote data instances.
+func (c Project) HealthAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	c.RenderJson(&projectHealthResult{
+		Kind:  "InstanceHealth",
+		Items: c.host.Layout.Health(),
+	})
+}
+
+// SpecInvalidateAction drops the cached table specs of a remote instance,
+// or of all instances if no instance is given. It takes a POST request.
+func (c Project) SpecInvalidateAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	rsp := projectSpecInvalidateResult{
+		Kind:     "SpecInvalidate",
+		Instance: c.Params.Value("instance"),
+	}
+	defer c.RenderJson(&rsp)
+
+	if c.Request.Method != "POST" {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "method not allowed")
+		return
+	}
+
+	if !authCsrfCheck(c.Controller, c.session) {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid csrf token")
+		return
+	}
+
+	c.host.Layout.InvalidateSpec(rsp.Instance)
+	rsp.Status = lynkapi.NewServiceStatusOK()
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+// testXmlText reports whether the string round-trips through the xml
+// decoder, which the fuzz tests parse the pre-rendered markup with.
+func testXmlText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if (r < 0x20 && r != '\t' && r != '\n') || r == 0xfffe || r == 0xffff {
+			return false
+		}
+	}
+	return true
+}
+
+type testXmlNode struct {
+	Name  string
+	Attrs map[string]string
+	Text  string
+	Nodes []*testXmlNode
+}
+
+// testXmlParse parses the markup into a tree, the comments are skipped.
+func testXmlParse(t *testing.T, s string) *testXmlNode {
+	t.Helper()
+
+	var (
+		dec   = xml.NewDecoder(strings.NewReader("<root>" + s + "</root>"))
+		stack []*testXmlNode
+		root  *testXmlNode
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid markup : %s\n%s", err.Error(), s)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			node := &testXmlNode{
+				Name:  tok.Name.Local,
+				Attrs: map[string]string{},
+			}
+			for _, a := range tok.Attr {
+				node.Attrs[a.Name.Local] = a.Value
+			}
+			if n := len(stack); n > 0 {
+				stack[n-1].Nodes = append(stack[n-1].Nodes, node)
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			stack[len(stack)-1].Text += string(tok)
+		}
+	}
+	return root
+}
+
+func FuzzTemplateLayout(f *testing.F) {
+
+	f.Add("main", "auto", "", "col", "200px", "border-end", "index")
+	f.Add("", "", "", "", "", "", "")
+	f.Add("a\"b", "calc(100% - 1px)", "x y,z", "<b>", "1px\";x:", "a\"><script>", "p--")
+	f.Add("n", "auto", "c", "{[=it.name]}", "auto", "", "{[~it.rows :row]}")
+	f.Add("n", "auto", "c", "c", "auto", "", "---")
+
+	f.Fuzz(func(t *testing.T, name, width, class, colName, colWidth, colClass, plName string) {
+
+		for _, s := range []string{name, width, class, colName, colWidth, colClass, plName} {
+			if !testXmlText(s) {
+				return
+			}
+		}
+
+		layout := &lynkui.TemplateLayout{
+			Name:       name,
+			Width:      width,
+			StyleClass: class,
+			Cols: []*lynkui.TemplateLayout{{
+				Name:       colName,
+				Width:      colWidth,
+				StyleClass: colClass,
+			}},
+		}
+
+		valid := true
+		for _, v := range []*lynkui.TemplateLayout{layout, layout.Cols[0]} {
+			if v.Name != "" && !lynkui.TemplateIdentValid(v.Name) {
+				valid = false
+			}
+			for _, c := range v.StyleClasses() {
+				if !lynkui.TemplateIdentValid(c) {
+					valid = false
+				}
+			}
+		}
+
+		pl := &lynkui.Pagelet{
+			Template: &lynkui.TemplateSpec{
+				Layout: layout,
+			},
+		}
+		err := pageletPreRender(nil, plName, pl)
+		if !valid {
+			if err == nil {
+				t.Fatalf("invalid identifiers accepted : %v", layout)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("valid layout rejected : %s", err.Error())
+		}
+
+		out := pl.Template.Html.Html
+		if strings.Contains(out, "{[") {
+			t.Fatalf("template tag in the layout markup :\n%s", out)
+		}
+
+		root := testXmlParse(t, out)
+		if len(root.Nodes) != 1 || len(root.Nodes[0].Nodes) != 1 {
+			t.Fatalf("unexpected layout markup :\n%s", out)
+		}
+		cols := root.Nodes[0].Nodes[0].Nodes
+		if len(cols) != 1 {
+			t.Fatalf("unexpected layout columns :\n%s", out)
+		}
+		if cols[0].Attrs["id"] != "lynkui-"+colName || cols[0].Text != colName {
+			t.Fatalf("column name (%s) not escaped :\n%s", colName, out)
+		}
+	})
+}
+
+func FuzzTemplateNav(f *testing.F) {
+
+	f.Add("", "menu")
+	f.Add("flex-column", "a\"b<c>")
+	f.Add("flex-row", "menu")
+	f.Add("\" onclick=\"x", "{[=it.name]}--")
+
+	// the template tags of main.js in the markup of a plain name
+	pl := &lynkui.Pagelet{
+		Template: &lynkui.TemplateSpec{
+			Nav: &lynkui.TemplateNav{},
+		},
+	}
+	if err := pageletPreRender(nil, "menu", pl); err != nil {
+		f.Fatal(err)
+	}
+	tags := strings.Count(pl.Template.Html.Html, "{[")
+
+	f.Fuzz(func(t *testing.T, display, plName string) {
+
+		if !testXmlText(display) || !testXmlText(plName) {
+			return
+		}
+
+		pl := &lynkui.Pagelet{
+			Template: &lynkui.TemplateSpec{
+				Nav: &lynkui.TemplateNav{
+					Display: display,
+				},
+			},
+		}
+		err := pageletPreRender(nil, plName, pl)
+		if display != "" && display != "flex-column" {
+			if err == nil {
+				t.Fatalf("invalid nav display (%s) accepted", display)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("valid nav rejected : %s", err.Error())
+		}
+
+		out := pl.Template.Html.Html
+		if n := strings.Count(out, "{["); n != tags {
+			t.Fatalf("%d template tags in the nav markup, want %d :\n%s", n, tags, out)
+		}
+
+		root := testXmlParse(t, out)
+		if len(root.Nodes) != 1 || len(root.Nodes[0].Nodes) != 1 {
+			t.Fatalf("unexpected nav markup :\n%s", out)
+		}
+		if li := root.Nodes[0].Nodes[0]; li.Attrs["x_pagelet"] != plName {
+			t.Fatalf("pagelet name (%s) not escaped :\n%s", plName, out)
+		}
+	})
+}
@@ -0,0 +1,312 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hooto/hlog4g/hlog"
+	"github.com/hooto/httpsrv"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const (
+	authCookieName  = "lynkui_session"
+	authCsrfHeader  = "X-Lynkui-Csrf"
+	authSessionTTL  = 12 * time.Hour
+	authSessionsMax = 10000
+)
+
+type authSession struct {
+	id      string
+	user    *lynkui.User
+	csrf    string
+	expired time.Time
+}
+
+type authSessions struct {
+	mu    sync.Mutex
+	items map[string]*authSession
+}
+
+func authToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (it *authSessions) create(user *lynkui.User) *authSession {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	tn := time.Now()
+	if it.items == nil {
+		it.items = map[string]*authSession{}
+	}
+	for id, s := range it.items {
+		if tn.After(s.expired) {
+			delete(it.items, id)
+		}
+	}
+	if len(it.items) >= authSessionsMax {
+		// drop the session closest to expiry
+		var last *authSession
+		for _, s := range it.items {
+			if last == nil || s.expired.Before(last.expired) {
+				last = s
+			}
+		}
+		delete(it.items, last.id)
+	}
+
+	s := &authSession{
+		id:      authToken(),
+		user:    user,
+		csrf:    authToken(),
+		expired: tn.Add(authSessionTTL),
+	}
+	it.items[s.id] = s
+	return s
+}
+
+func (it *authSessions) get(id string) *authSession {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	s, ok := it.items[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(s.expired) {
+		delete(it.items, id)
+		return nil
+	}
+	return s
+}
+
+func (it *authSessions) del(id string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	delete(it.items, id)
+}
+
+func authCookieSet(c *httpsrv.Controller, h *Host, s *authSession) {
+	ck := &http.Cookie{
+		Name:     authCookieName,
+		Path:     h.Config.UrlEntryPath,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure: c.Request.TLS != nil ||
+			c.Request.Header.Get("X-Forwarded-Proto") == "https",
+	}
+	if s != nil {
+		ck.Value, ck.Expires = s.id, s.expired
+	} else {
+		ck.MaxAge = -1
+	}
+	http.SetCookie(c.Response.Out, ck)
+}
+
+// authSessionGet returns the session of the request. Without a session
+// cookie, the request is passed to the RequestAuthenticator of the host app
+// if there is one, and a session is started for the user it returns.
+func authSessionGet(c *httpsrv.Controller, h *Host) *authSession {
+
+	// nested entry paths may send several cookies of the same name
+	for _, ck := range c.Request.Cookies() {
+		if ck.Name != authCookieName {
+			continue
+		}
+		if s := h.sessions.get(ck.Value); s != nil {
+			return s
+		}
+	}
+
+	ra, ok := h.Config.Authenticator.(lynkui.RequestAuthenticator)
+	if !ok {
+		return nil
+	}
+	user, err := ra.AuthenticateRequest(c.Request.Request)
+	if err != nil {
+		hlog.Printf("info", "auth request fail : %s", err.Error())
+		return nil
+	}
+	if user == nil {
+		return nil
+	}
+
+	s := h.sessions.create(user)
+	authCookieSet(c, h, s)
+	return s
+}
+
+// authInit resolves the session of the request, it is called by the Init
+// method of the api controllers after hostInit. Requests without a session
+// are answered with 401 if an authenticator is setup.
+func authInit(c *httpsrv.Controller, h *Host) (*authSession, int) {
+
+	if h.Config.Authenticator == nil {
+		return nil, 0
+	}
+
+	if s := authSessionGet(c, h); s != nil {
+		return s, 0
+	}
+
+	// the response buffer is not flushed if Init fails, write it out directly
+	c.Response.Out.Header().Set("Content-Type", "application/json")
+	c.Response.Out.WriteHeader(http.StatusUnauthorized)
+	c.Response.Out.Write(jsonEncode(&authResult{
+		Kind:   "AuthSession",
+		Status: lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "login required"),
+		Enable: true,
+	}))
+	return nil, 1
+}
+
+// authCsrfCheck verifies the CSRF token header of a data changing request.
+func authCsrfCheck(c *httpsrv.Controller, s *authSession) bool {
+	if s == nil {
+		return true
+	}
+	v := c.Request.Header.Get(authCsrfHeader)
+	return v != "" && subtle.ConstantTimeCompare([]byte(v), []byte(s.csrf)) == 1
+}
+
+type Auth struct {
+	*httpsrv.Controller
+	host *Host
+}
+
+func (c *Auth) Init() int {
+	var rc int
+	c.host, rc = hostInit(c.Controller)
+	return rc
+}
+
+type authResult struct {
+	Kind      string                 `json:"kind"`
+	Status    *lynkapi.ServiceStatus `json:"status,omitempty"`
+	Enable    bool                   `json:"enable"`
+	User      *lynkui.User           `json:"user,omitempty"`
+	CsrfToken string                 `json:"csrf_token,omitempty"`
+}
+
+type authLoginRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func (c Auth) LoginAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	rsp := authResult{
+		Kind:   "AuthSession",
+		Enable: c.host.Config.Authenticator != nil,
+	}
+	defer c.RenderJson(&rsp)
+
+	if !rsp.Enable {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "authentication not enabled")
+		return
+	}
+
+	if c.Request.Method != "POST" {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, "method not allowed")
+		return
+	}
+
+	var req authLoginRequest
+	if err := c.Request.JsonDecode(&req); err != nil {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_BadRequest, err.Error())
+		return
+	}
+
+	if req.Name == "" || req.Password == "" || len(req.Name) > 100 || len(req.Password) > 1024 {
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid name or password")
+		return
+	}
+
+	user, err := c.host.Config.Authenticator.Authenticate(req.Name, req.Password)
+	if err != nil || user == nil {
+		if err != nil {
+			hlog.Printf("info", "auth login %s from %s fail : %s", req.Name, c.Request.RemoteAddr, err.Error())
+		}
+		rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid name or password")
+		return
+	}
+
+	s := c.host.sessions.create(user)
+	authCookieSet(c.Controller, c.host, s)
+
+	hlog.Printf("info", "auth login %s from %s", user.Name, c.Request.RemoteAddr)
+
+	rsp.Status = lynkapi.NewServiceStatusOK()
+	rsp.User, rsp.CsrfToken = s.user, s.csrf
+}
+
+func (c Auth) LogoutAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	rsp := authResult{
+		Kind:   "AuthSession",
+		Enable: c.host.Config.Authenticator != nil,
+	}
+	defer c.RenderJson(&rsp)
+
+	if rsp.Enable {
+		if s := authSessionGet(c.Controller, c.host); s != nil {
+			if !authCsrfCheck(c.Controller, s) {
+				rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "invalid csrf token")
+				return
+			}
+			c.host.sessions.del(s.id)
+		}
+		authCookieSet(c.Controller, c.host, nil)
+	}
+
+	rsp.Status = lynkapi.NewServiceStatusOK()
+}
+
+func (c Auth) SessionAction() {
+	c.AutoRender = false
+	c.Response.Out.Header().Set("Cache-Control", "no-cache")
+
+	rsp := authResult{
+		Kind:   "AuthSession",
+		Enable: c.host.Config.Authenticator != nil,
+	}
+	defer c.RenderJson(&rsp)
+
+	if rsp.Enable {
+		s := authSessionGet(c.Controller, c.host)
+		if s == nil {
+			rsp.Status = lynkapi.NewServiceStatus(lynkapi.StatusCode_UnAuth, "login required")
+			return
+		}
+		rsp.User, rsp.CsrfToken = s.user, s.csrf
+	}
+
+	rsp.Status = lynkapi.NewServiceStatusOK()
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hooto/httpsrv"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+	"github.com/lynkdb/lynkui/internal/access"
+	"github.com/lynkdb/lynkui/internal/audit"
+	"github.com/lynkdb/lynkui/internal/data"
+	"github.com/lynkdb/lynkui/internal/status"
+)
+
+// Host is the state of one project mounted at Config.UrlEntryPath.
+type Host struct {
+	Config *lynkui.ServiceConfig
+	Layout *data.LayoutManager
+	Assets *status.Sets
+	Access *access.Manager
+	Audit  *audit.Log
+
+	sessions authSessions
+}
+
+var hosts struct {
+	mu    sync.RWMutex
+	items []*Host // ordered by the length of UrlEntryPath, longest first
+}
+
+func hostRegister(h *Host) error {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	for _, v := range hosts.items {
+		if v.Config.UrlEntryPath == h.Config.UrlEntryPath {
+			return fmt.Errorf("url_entry_path (%s) already in use", h.Config.UrlEntryPath)
+		}
+	}
+	hosts.items = append(hosts.items, h)
+	sort.SliceStable(hosts.items, func(i, j int) bool {
+		return len(hosts.items[i].Config.UrlEntryPath) > len(hosts.items[j].Config.UrlEntryPath)
+	})
+	return nil
+}
+
+func hostLookup(urlPath string) *Host {
+	hosts.mu.RLock()
+	defer hosts.mu.RUnlock()
+	urlPath = strings.ToLower(urlPath)
+	for _, h := range hosts.items {
+		entry := strings.ToLower(h.Config.UrlEntryPath)
+		if entry == "/" || urlPath == entry ||
+			strings.HasPrefix(urlPath, entry+"/") {
+			return h
+		}
+	}
+	return nil
+}
+
+// hostInit resolves the project of the request, it is called by the Init
+// method of the controllers.
+func hostInit(c *httpsrv.Controller) (*Host, int) {
+	h := hostLookup(c.Request.UrlPath())
+	if h == nil {
+		c.Response.WriteHeader(http.StatusNotFound)
+		return nil, 1
+	}
+	return h, 0
+}
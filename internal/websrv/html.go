@@ -0,0 +1,79 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlBuilder writes markup with the attribute values and the text escaped,
+// the pre-rendered templates of pageletPreRender are built on it.
+type htmlBuilder struct {
+	strings.Builder
+}
+
+type htmlAttr struct {
+	name  string
+	value string
+	// the value is a template expression of main.js, e.g. "nav-{[!it.name]}",
+	// which is kept as is after the escaping
+	tpl bool
+}
+
+func (b *htmlBuilder) open(tag string, attrs ...htmlAttr) *htmlBuilder {
+	b.WriteString("<" + tag)
+	for _, a := range attrs {
+		v := html.EscapeString(a.value)
+		if !a.tpl {
+			v = htmlTplEscape(v)
+		}
+		b.WriteString(" " + a.name + "=\"" + v + "\"")
+	}
+	b.WriteString(">")
+	return b
+}
+
+func (b *htmlBuilder) close(tag string) *htmlBuilder {
+	b.WriteString("</" + tag + ">")
+	return b
+}
+
+func (b *htmlBuilder) text(s string) *htmlBuilder {
+	b.WriteString(htmlTplEscape(html.EscapeString(s)))
+	return b
+}
+
+// tpl writes a template expression or tag of main.js, e.g. "{[~it.rows :row]}".
+func (b *htmlBuilder) tpl(s string) *htmlBuilder {
+	b.WriteString(s)
+	return b
+}
+
+func (b *htmlBuilder) comment(s string) *htmlBuilder {
+	s = htmlTplEscape(html.EscapeString(s))
+	// "---" is "- --" after one pass
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "- -")
+	}
+	b.WriteString("<!-- " + s + " -->")
+	return b
+}
+
+// htmlTplEscape keeps the literal text from opening a template tag of
+// main.js, the browser decodes the entity back.
+func htmlTplEscape(s string) string {
+	return strings.ReplaceAll(s, "{[", "&#123;[")
+}
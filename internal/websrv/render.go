@@ -0,0 +1,584 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hooto/hlog4g/hlog"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/lynkdb/lynkapi/go/lynkapi"
+
+	"github.com/lynkdb/lynkui/go/lynkui"
+)
+
+const (
+	pageRenderItemsMax = 100
+	pageRenderTextMax  = 512
+)
+
+// pageRender resolves the pagelet tree of the index pagelet to HTML on the
+// server. The pagelets are walked as main.js does, each one is rendered into
+// the layout column named by its output. The links of navs, sort headers and
+// pagers are plain URLs, so the page works without JavaScript.
+type pageRender struct {
+	d     Datalet
+	query url.Values
+	items []*pageRenderItem
+	index map[string]*pageRenderItem
+}
+
+type pageRenderItem struct {
+	pl     *lynkui.Pagelet
+	filter *lynkapi.DataQuery_Filter // set by a nav click
+	active string                    // the clicked nav item
+	placed bool
+	html   template.HTML
+}
+
+// renderParams are the datalet query params of a server rendered pagelet.
+type renderParams map[string]string
+
+func (it renderParams) Value(name string) string {
+	return it[name]
+}
+
+type renderPageData struct {
+	Base    string
+	Body    template.HTML
+	Message string
+	Client  string
+}
+
+type renderLayoutData struct {
+	Name  string
+	Class string
+	Style template.CSS
+	Cols  []*renderLayoutCol
+}
+
+type renderLayoutCol struct {
+	Name  string
+	Class string
+	Style template.CSS
+	Html  template.HTML
+}
+
+type renderNavData struct {
+	Name  string
+	Class string
+	Items []*renderNavItem
+}
+
+type renderNavItem struct {
+	Id     string
+	Title  string
+	Href   string
+	Active bool
+}
+
+type renderTableData struct {
+	Name       string
+	Title      string
+	Error      string
+	Fields     []*renderTableField
+	Rows       []*renderTableRow
+	ExportHref string
+	FirstHref  string
+	NextHref   string
+	Total      int64 // 0 if not counted
+}
+
+type renderTableField struct {
+	Name  string
+	Field string
+	Class string
+	Href  string
+	Sort  string
+}
+
+type renderTableRow struct {
+	Id    string
+	Cells []*renderTableCell
+}
+
+type renderTableCell struct {
+	Field string
+	Class string
+	Html  template.HTML
+}
+
+func newPageRender(d Datalet, query url.Values) *pageRender {
+	return &pageRender{
+		d:     d,
+		query: query,
+		index: map[string]*pageRenderItem{},
+	}
+}
+
+// walk adds the pagelet and its next pagelets the user may open.
+func (r *pageRender) walk(name string) *pageRenderItem {
+
+	var (
+		host  = r.d.host
+		user  = sessionUser(r.d.session)
+		queue = []string{name}
+	)
+
+	for len(queue) > 0 && len(r.items) < pageRenderItemsMax {
+
+		name, queue = queue[0], queue[1:]
+		if _, ok := r.index[name]; ok || !host.Access.Pagelet(user, name) {
+			continue
+		}
+
+		pl := host.Assets.Pagelet(name)
+		if pl == nil {
+			continue
+		}
+		if pl.Datalet != nil && pl.Datalet.TableName != "" {
+			if spec := dataletTableSpec(host.Layout, pl); spec != nil {
+				pl.Datalet.TableSpec = spec
+			}
+		}
+		accessPagelet(host, user, pl)
+
+		item := &pageRenderItem{pl: pl}
+		r.items = append(r.items, item)
+		r.index[name] = item
+
+		for _, v := range pl.NextPagelets {
+			queue = append(queue, v.Name)
+		}
+	}
+
+	return r.index[name]
+}
+
+// event applies the nav click of the event and dict_id params, the pagelet
+// of the nav event lists the rows of the clicked dict item.
+func (r *pageRender) event() {
+
+	var (
+		nav = r.index[r.query.Get("event")]
+		id  = r.query.Get("dict_id")
+	)
+	if nav == nil || id == "" || nav.pl.Event == nil ||
+		nav.pl.Event.Name != "onclick" || nav.pl.Event.Pagelet == "" {
+		return
+	}
+
+	item := r.index[nav.pl.Event.Pagelet]
+	if item == nil {
+		item = r.walk(nav.pl.Event.Pagelet)
+	}
+	if item == nil || item.pl.Datalet == nil {
+		return
+	}
+
+	nav.active = id
+	item.filter = &lynkapi.DataQuery_Filter{
+		Field: "dict_id",
+		Value: structpb.NewStringValue(id),
+	}
+}
+
+// Render returns the HTML of the tree of the named pagelet.
+func (r *pageRender) Render(name string) (template.HTML, error) {
+
+	if !r.d.host.Access.Pagelet(sessionUser(r.d.session), name) {
+		return "", fmt.Errorf("pagelet (%s) access denied", name)
+	}
+	if r.walk(name) == nil {
+		return "", fmt.Errorf("pagelet (%s) not found", name)
+	}
+	r.event()
+
+	// the children come after their parents, the layouts are rendered
+	// after the pagelets of their columns
+	for i := len(r.items) - 1; i >= 0; i-- {
+		html, err := r.render(r.items[i])
+		if err != nil {
+			return "", err
+		}
+		r.items[i].html = html
+	}
+
+	var buf strings.Builder
+	for _, item := range r.items {
+		if !item.placed {
+			buf.WriteString(string(item.html))
+		}
+	}
+	return template.HTML(buf.String()), nil
+}
+
+func (r *pageRender) render(item *pageRenderItem) (template.HTML, error) {
+
+	pl := item.pl
+	if pl.Template == nil || pl.Output == "" {
+		return "", nil
+	}
+
+	var (
+		tpl  = "html"
+		data interface{}
+	)
+
+	switch {
+
+	case pl.Template.Layout != nil:
+		if err := pl.Template.Layout.Validate(); err != nil {
+			return "", err
+		}
+		tpl, data = "layout", r.layout(item)
+
+	case pl.Template.Nav != nil:
+		if err := pl.Template.Nav.Validate(); err != nil {
+			return "", err
+		}
+		tpl, data = "nav", r.nav(item)
+
+	case pl.Datalet != nil && pl.Datalet.TableName != "":
+		tpl, data = "table", r.table(item)
+
+	case pl.Template.Html != nil:
+		html := pl.Template.Html.Html
+		if html == "" {
+			if err := pageletPreRender(r.d.host.Assets, pl.Name, pl); err != nil {
+				return "", err
+			}
+			html = pl.Template.Html.Html
+		}
+		// the templates of main.js are rendered in the browser only
+		if strings.Contains(html, "{[") {
+			html = fmt.Sprintf("<!-- pagelet:%s requires javascript -->", pl.Name)
+		}
+		data = template.HTML(html)
+
+	default:
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := renderTemplates.ExecuteTemplate(&buf, tpl, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+func (r *pageRender) layout(item *pageRenderItem) *renderLayoutData {
+
+	layout := item.pl.Template.Layout.Refix()
+
+	data := &renderLayoutData{
+		Name:  item.pl.Name,
+		Class: "row _lynkui-row" + colUnitFilter("lynkui-row-", layout.Width),
+		Style: template.CSS(colCss(layout)),
+	}
+
+	for _, v := range layout.Cols {
+		col := &renderLayoutCol{
+			Name:  v.Name,
+			Class: "_lynkui-col" + colUnitFilter("lynkui-col-", v.Width) + colClassFilter(v),
+			Style: template.CSS(colCss(v)),
+		}
+		var buf strings.Builder
+		for _, it := range r.items {
+			if it != item && !it.placed && v.Name != "" && it.pl.Output == v.Name {
+				buf.WriteString(string(it.html))
+				it.placed = true
+			}
+		}
+		col.Html = template.HTML(buf.String())
+		data.Cols = append(data.Cols, col)
+	}
+
+	return data
+}
+
+func (r *pageRender) nav(item *pageRenderItem) *renderNavData {
+
+	data := &renderNavData{
+		Name:  item.pl.Name,
+		Class: navClassFilter(item.pl.Template.Nav),
+	}
+
+	rs, _, msg := r.rows(item)
+	if msg != "" || rs == nil {
+		return data
+	}
+
+	for _, row := range rs.Rows {
+		fields := dataRowFields(rs.Spec, row)
+		v := &renderNavItem{
+			Id:    fields["id"].GetStringValue(),
+			Title: fields["display_name"].GetStringValue(),
+			Href:  "#" + fields["name"].GetStringValue(),
+		}
+		if v.Title == "" {
+			v.Title = fields["name"].GetStringValue()
+		}
+		if item.pl.Event != nil && item.pl.Event.Name == "onclick" && v.Id != "" {
+			v.Href = r.href(map[string]string{
+				"event":   item.pl.Name,
+				"dict_id": v.Id,
+			})
+			v.Active = v.Id == item.active
+		}
+		data.Items = append(data.Items, v)
+	}
+
+	return data
+}
+
+func (r *pageRender) table(item *pageRenderItem) *renderTableData {
+
+	pl := item.pl
+
+	data := &renderTableData{
+		Name:  pl.Name,
+		Title: pl.Name,
+	}
+	if pl.DisplayName != "" {
+		data.Title = pl.DisplayName
+	}
+
+	rs, total, msg := r.rows(item)
+	if msg != "" {
+		data.Error = msg
+		return data
+	}
+	if total > 0 {
+		data.Total = total
+	}
+
+	params := r.params(item)
+	export := url.Values{"pagelet": {pl.Name}}
+	for _, k := range []string{"query_filter", "sort_field", "sort_type"} {
+		if params[k] != "" {
+			export.Set(k, params[k])
+		}
+	}
+	data.ExportHref = r.d.host.Config.UrlEntryPath + "/api/v1/datalet/export?" + export.Encode()
+
+	if rs == nil || rs.Spec == nil || len(rs.Rows) == 0 {
+		return data
+	}
+
+	var display []string
+	if pl.Datalet.List != nil {
+		display = pl.Datalet.List.DisplayFields
+	}
+
+	sortField, sortType := params["sort_field"], params["sort_type"]
+	if sortField == "" && pl.Datalet.List != nil && pl.Datalet.List.Sort != nil {
+		sortField, sortType = pl.Datalet.List.Sort.Field, pl.Datalet.List.Sort.Type
+		if sortType == "" {
+			sortType = "asc"
+		}
+	}
+
+	var fields []*lynkapi.FieldSpec
+	for _, field := range rs.Spec.Fields {
+		if len(display) > 0 && !slices.Contains(display, field.TagName) {
+			continue
+		}
+
+		v := &renderTableField{
+			Name:  field.Name,
+			Field: field.TagName,
+			Class: "cw",
+		}
+		if len(display) > 0 {
+			if s := field.Styles["list_width"].GetStringValue(); s != "" {
+				v.Class = s
+			}
+		}
+		if dataletSortable(field) {
+			next := "asc"
+			if field.TagName == sortField {
+				v.Sort = sortType
+				if sortType == "asc" {
+					next = "desc"
+				}
+			}
+			v.Href = r.href(map[string]string{
+				"sort." + pl.Name:   field.TagName + ":" + next,
+				"offset." + pl.Name: "",
+			})
+		}
+		data.Fields = append(data.Fields, v)
+		fields = append(fields, field)
+	}
+
+	for _, row := range rs.Rows {
+		values := dataRowFields(rs.Spec, row)
+		v := &renderTableRow{
+			Id: row.Id,
+		}
+		for i, field := range fields {
+			v.Cells = append(v.Cells, &renderTableCell{
+				Field: field.TagName,
+				Class: data.Fields[i].Class,
+				Html:  renderFieldValue(field, values[field.TagName]),
+			})
+		}
+		data.Rows = append(data.Rows, v)
+	}
+
+	if params["offset"] != "" {
+		data.FirstHref = r.href(map[string]string{
+			"offset." + pl.Name: "",
+		})
+	}
+	if rs.NextOffset != "" {
+		data.NextHref = r.href(map[string]string{
+			"offset." + pl.Name: rs.NextOffset,
+		})
+	}
+
+	return data
+}
+
+// params returns the datalet params of the pagelet, the offset and the sort
+// of each datalet of the page are kept in params suffixed by its name.
+func (r *pageRender) params(item *pageRenderItem) renderParams {
+
+	var (
+		name   = item.pl.Name
+		params = renderParams{
+			"offset": r.query.Get("offset." + name),
+		}
+	)
+
+	if v := r.query.Get("sort." + name); v != "" {
+		if n := strings.LastIndexByte(v, ':'); n > 0 {
+			params["sort_field"], params["sort_type"] = v[:n], v[n+1:]
+		} else {
+			params["sort_field"] = v
+		}
+	}
+
+	if item.filter != nil {
+		params["query_filter"] = base64Encode(item.filter)
+	}
+
+	return params
+}
+
+// rows runs the datalet query of the pagelet as RunAction does, an empty
+// result is not an error. total is the number of the matching rows, or -1
+// if the instance does not count them.
+func (r *pageRender) rows(item *pageRenderItem) (*lynkapi.DataResult, int64, string) {
+
+	var (
+		host = r.d.host
+		user = sessionUser(r.d.session)
+		g    = host.Access.Table(user, item.pl.Datalet.TableName)
+	)
+
+	if !g.Read {
+		return nil, -1, "access denied"
+	}
+
+	pl, err := dataletUserFilter(item.pl, user)
+	if err != nil {
+		hlog.Printf("warn", "pagelet %s : %s", item.pl.Name, err.Error())
+		return nil, -1, "access denied"
+	}
+
+	query, err := dataletQuery(host.Layout, pl, g, r.params(item))
+	if err != nil {
+		return nil, -1, err.Error()
+	}
+
+	rs, total, err := host.Layout.QueryPageContext(r.d.dataContext(), query)
+	if err != nil {
+		if status := lynkapi.ParseError(err); status.Code == lynkapi.StatusCode_NotFound {
+			return nil, -1, ""
+		}
+		hlog.Printf("info", "pagelet %s query : %s", item.pl.Name, err.Error())
+		return nil, -1, lynkapi.ParseError(err).Message
+	}
+
+	accessResult(rs, g)
+	r.d.accessNavRows(rs)
+
+	return rs, total, ""
+}
+
+// href returns the URL of the page with the params set, empty values are
+// removed.
+func (r *pageRender) href(set map[string]string) string {
+	query := url.Values{}
+	for k, v := range r.query {
+		query[k] = v
+	}
+	for k, v := range set {
+		if v == "" {
+			query.Del(k)
+		} else {
+			query.Set(k, v)
+		}
+	}
+	return "?" + query.Encode()
+}
+
+// renderFieldValue formats the value of a table cell as main.js does in
+// pagelet.rowFieldValue.
+func renderFieldValue(field *lynkapi.FieldSpec, v *structpb.Value) template.HTML {
+
+	s := dataletExportText(field, v)
+	if s == "" {
+		return ""
+	}
+
+	switch field.Styles["unit"].GetStringValue() {
+	case "unix-seconds", "unix-milliseconds", "unix-microseconds":
+		n := v.GetNumberValue()
+		switch field.Styles["unit"].GetStringValue() {
+		case "unix-milliseconds":
+			n /= 1e3
+		case "unix-microseconds":
+			n /= 1e6
+		}
+		return template.HTML(template.HTMLEscapeString(
+			time.Unix(int64(n), 0).Format("2006-01-02 15:04:05")))
+	}
+
+	n := 0
+	if m := field.Styles["list_max_len"].GetNumberValue(); m > 0 {
+		n = int(m)
+	} else if field.Type == lynkapi.FieldSpec_String {
+		n = pageRenderTextMax
+	}
+	if n > 0 && utf8.RuneCountInString(s) > n {
+		s = string([]rune(s)[:n])
+	}
+
+	if field.Styles["text_type"].GetStringValue() == "md" {
+		return template.HTML("<pre>" + template.HTMLEscapeString(s) + "</pre>")
+	}
+	return template.HTML(template.HTMLEscapeString(s))
+}
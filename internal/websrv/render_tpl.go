@@ -0,0 +1,129 @@
+// Copyright 2024 Eryx <evorui at gmail dot com>, All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websrv
+
+import (
+	"html/template"
+)
+
+// renderTemplates are the server side versions of the layout and nav
+// templates of pageletPreRender and of core/v1/block-table-list.html.
+var renderTemplates = template.Must(template.New("").Parse(`
+{{define "page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>lynkui</title>
+  <link rel="stylesheet" href="{{.Base}}/~/bs/v5/css/bootstrap.css">
+  <link rel="stylesheet" href="{{.Base}}/~/lynkui/main.css">
+  <link rel="stylesheet" href="{{.Base}}/~/lynkui/main-v2.css">
+</head>
+<body id="lynkui-body-content">
+{{- if .Message}}
+<div class="alert alert-warning m-3">{{.Message}}{{if .Client}} <a href="{{.Client}}">Open the console</a>{{end}}</div>
+{{- end}}
+{{.Body}}
+</body>
+</html>
+{{end}}
+
+{{define "html"}}{{.}}{{end}}
+
+{{define "layout"}}<div class="container-fluid _lynkui-container">
+<div class="{{.Class}}"{{if .Style}} style="{{.Style}}"{{end}}>
+{{- range .Cols}}
+  <div id="lynkui-{{.Name}}" class="{{.Class}}"{{if .Style}} style="{{.Style}}"{{end}}>{{.Html}}</div>
+{{- end}}
+</div>
+</div>
+{{end}}
+
+{{define "nav"}}<nav id="nav-{{.Name}}" class="nav lynkui-nav lynkui-gap-box{{.Class}}">
+{{- range .Items}}
+<li id="nav-item-{{.Id}}" class="nav-item lynkui-nav-item{{if .Active}} active{{end}}">
+  <a id="nav-link-{{$.Name}}-{{.Id}}" class="nav-link lynkui-nav-link" href="{{.Href}}">{{.Title}}</a>
+</li>
+{{- end}}
+</nav>
+{{end}}
+
+{{define "table"}}<div class="lynkui-block" id="lynkui-datalet-{{.Name}}">
+  <div class="lynkui-block-head d-flex justify-content-between">
+    <div class="lynkui-block-title">{{.Title}}</div>
+    <div class="lynkui-block-toolbar">
+      {{- if .ExportHref}}
+      <a class="btn btn-outline-secondary btn-sm" href="{{.ExportHref}}&format=csv">CSV</a>
+      <a class="btn btn-outline-secondary btn-sm" href="{{.ExportHref}}&format=jsonl">JSONL</a>
+      {{- end}}
+    </div>
+  </div>
+  {{- if .Error}}
+  <div class="lynkui-block-body alert alert-warning">{{.Error}}</div>
+  {{- else if not .Rows}}
+  <div class="lynkui-block-body alert alert-light">Data Not Found</div>
+  {{- else}}
+  <div class="lynkui-block-body lynkui-scroll">
+    <table class="table lynkui-table">
+      <colgroup class="table-row">
+        {{- range .Fields}}
+        <col class="{{.Class}}" />
+        {{- end}}
+      </colgroup>
+      <thead>
+        <tr class="_table-row">
+          {{- range .Fields}}
+          <th class="{{.Class}}{{if .Href}} lynkui-datalet-sort{{end}}"{{if .Href}} x_pagelet="{{$.Name}}" x_field="{{.Field}}"{{end}}>
+            {{- if .Href}}
+            <a href="{{.Href}}">{{.Name}}</a>
+            {{- else}}
+            {{.Name}}
+            {{- end}}
+            {{- if eq .Sort "desc"}}
+            <span class="lynkui-datalet-sort-desc"></span>
+            {{- else if .Sort}}
+            <span class="lynkui-datalet-sort-asc"></span>
+            {{- end}}
+          </th>
+          {{- end}}
+        </tr>
+      </thead>
+      <tbody id="data-result-list">
+        {{- range $row := .Rows}}
+        <tr id="data-row-{{$row.Id}}" class="_table-row">
+          {{- range $row.Cells}}
+          <td id="data-row-{{$row.Id}}-field-{{.Field}}" class="{{.Class}}">{{.Html}}</td>
+          {{- end}}
+        </tr>
+        {{- end}}
+      </tbody>
+    </table>
+  </div>
+  {{- end}}
+  {{- if or .FirstHref .NextHref}}
+  <div class="lynkui-block-foot d-flex justify-content-end align-items-center">
+    {{- if .FirstHref}}
+    <a class="btn btn-outline-dark btn-sm" href="{{.FirstHref}}">First</a>
+    {{- end}}
+    {{- if .Total}}
+    <span class="px-2">({{.Total}} rows)</span>
+    {{- end}}
+    {{- if .NextHref}}
+    <a class="btn btn-outline-dark btn-sm ms-2" href="{{.NextHref}}">Next</a>
+    {{- end}}
+  </div>
+  {{- end}}
+</div>
+{{end}}
+`))
@@ -15,13 +15,22 @@
 package websrv
 
 import (
+	"bytes"
+	"net/http"
+
+	"github.com/hooto/hlog4g/hlog"
 	"github.com/hooto/httpsrv"
 
-	"github.com/lynkdb/lynkui/go/lynkui"
 	"github.com/lynkdb/lynkui/internal/bindata"
 )
 
-func Setup(s *httpsrv.Service, cfg *lynkui.ServiceConfig) error {
+func Setup(s *httpsrv.Service, h *Host) error {
+
+	if err := hostRegister(h); err != nil {
+		return err
+	}
+
+	cfg := h.Config
 
 	{
 		mod := httpsrv.NewModule()
@@ -44,7 +53,7 @@ func Setup(s *httpsrv.Service, cfg *lynkui.ServiceConfig) error {
 	{
 		mod := httpsrv.NewModule()
 
-		mod.RegisterController(new(Pagelet), new(Datalet))
+		mod.RegisterController(new(Pagelet), new(Datalet), new(Project), new(Auth))
 
 		s.HandleModule(cfg.UrlEntryPath+"/api/v1", mod)
 	}
@@ -54,13 +63,45 @@ func Setup(s *httpsrv.Service, cfg *lynkui.ServiceConfig) error {
 
 type Index struct {
 	*httpsrv.Controller
+	host *Host
 }
 
+func (c *Index) Init() int {
+	var rc int
+	c.host, rc = hostInit(c.Controller)
+	return rc
+}
+
+// IndexAction renders the console shell, main.js opens the login form in it
+// if authentication is enabled and the request has no session. The render
+// param (server or client) overrides ServiceConfig.ServerRender.
 func (c Index) IndexAction() {
 
 	c.AutoRender = false
 	c.Response.Out.Header().Set("Cache-Control", "no-cache")
 
+	switch c.Params.Value("render") {
+	case "server":
+		c.renderServer()
+		return
+	case "client":
+	default:
+		if c.host.Config.ServerRender {
+			c.renderServer()
+			return
+		}
+	}
+
+	auth := map[string]interface{}{
+		"enable": c.host.Config.Authenticator != nil,
+	}
+	if c.host.Config.Authenticator != nil {
+		if s := authSessionGet(c.Controller, c.host); s != nil {
+			auth["user"], auth["csrf_token"] = s.user, s.csrf
+		}
+	}
+	c.Data["lynkui_session"] = auth
+
 	c.RenderHTML(`<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -70,6 +111,7 @@ func (c Index) IndexAction() {
   <script type="text/javascript">
     lynkui.basepath = "{{.URL_MOD_PATH}}";
     lynkui.uipath = "~";
+    lynkui.session = {{.lynkui_session}};
     window.onload = lynkui.main();
   </script>
 </head>
@@ -77,3 +119,46 @@ func (c Index) IndexAction() {
 </html>
 `)
 }
+
+// renderServer renders the pagelet tree of the index pagelet on the server,
+// see pageRender.
+func (c Index) renderServer() {
+
+	var (
+		data = renderPageData{
+			Base:   c.host.Config.UrlEntryPath,
+			Client: "?render=client",
+		}
+		session *authSession
+	)
+
+	if c.host.Config.Authenticator != nil {
+		if session = authSessionGet(c.Controller, c.host); session == nil {
+			data.Message = "Sign in required."
+		}
+	}
+
+	if data.Message == "" {
+		r := newPageRender(Datalet{
+			Controller: c.Controller,
+			host:       c.host,
+			session:    session,
+		}, c.Request.URL.Query())
+		body, err := r.Render("index")
+		if err != nil {
+			hlog.Printf("warn", "server render : %s", err.Error())
+			data.Message = err.Error()
+		}
+		data.Body = body
+	}
+
+	var buf bytes.Buffer
+	if err := renderTemplates.ExecuteTemplate(&buf, "page", &data); err != nil {
+		hlog.Printf("warn", "server render : %s", err.Error())
+		c.Response.Out.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Response.Out.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Response.Out.Write(buf.Bytes())
+}